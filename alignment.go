@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// quotaPolicy is the --policy file: platform rules the reports check apps
+// against.
+type quotaPolicy struct {
+	// QuotaStep is the memory granularity the platform bills or schedules
+	// in, e.g. "256M". Quotas that aren't a multiple of it are flagged.
+	QuotaStep string `json:"quota_step"`
+}
+
+var policySchema = &configSchema{kind: "object", fields: map[string]*configSchema{
+	"quota_step": stringSchema,
+}}
+
+func loadPolicyFile(opts *options) error {
+
+	data, err := ioutil.ReadFile(opts.policyFile)
+	if err != nil {
+		return err
+	}
+
+	if err := validateConfig(opts.policyFile, data, policySchema); err != nil {
+		return err
+	}
+
+	policy := quotaPolicy{}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return fmt.Errorf("reading %s: %v", opts.policyFile, err)
+	}
+
+	if policy.QuotaStep != "" {
+		step, err := parseSize(policy.QuotaStep)
+		if err != nil {
+			return fmt.Errorf("%s: quota_step: %v", opts.policyFile, err)
+		}
+		if step < 1<<20 {
+			return fmt.Errorf("%s: quota_step must be at least 1M", opts.policyFile)
+		}
+		opts.quotaStep = step
+	}
+	return nil
+}
+
+// markMisalignedQuotas suggests, for quotas that aren't a multiple of step
+// (1000M, 3G plus a few megabytes), the next aligned quota up, so aligning
+// never takes memory away from an app.
+func markMisalignedQuotas(appStats []appStatSummary, step int) {
+
+	for i := range appStats {
+		app := &appStats[i]
+		if app.MemoryAlloc > 0 && app.MemoryAlloc%step != 0 {
+			app.AlignedQuota = (app.MemoryAlloc + step - 1) / step * step
+		}
+	}
+}
+
+func quotaAlignment(s *appStatSummary) string {
+	if s.AlignedQuota == 0 {
+		return ""
+	}
+	return "→ " + formatSize(s.AlignedQuota)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type orgSummary struct {
+	Org          string   `json:"org"`
+	Apps         int      `json:"apps"`
+	Spaces       []string `json:"spaces"`
+	MemoryAlloc  int      `json:"memory_alloc"`
+	AvgMemoryUse int      `json:"avg_memory_use"`
+	Waste        int      `json:"waste"`
+	Savings      int      `json:"savings"`
+}
+
+func registerAPI(mux *http.ServeMux, store *historyStore) {
+
+	mux.HandleFunc("/api/runs", func(w http.ResponseWriter, r *http.Request) {
+		runs, err := store.runs()
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeAPIResponse(w, runs)
+	})
+
+	mux.HandleFunc("/api/apps/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/apps/"), "/")
+		if len(parts) != 2 || parts[1] != "history" {
+			writeAPIError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		points, err := store.appHistoryByGUID(parts[0])
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if len(points) == 0 {
+			writeAPIError(w, http.StatusNotFound, "no history for app "+parts[0])
+			return
+		}
+		writeAPIResponse(w, points)
+	})
+
+	mux.HandleFunc("/api/orgs/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/orgs/"), "/")
+		if len(parts) != 2 || parts[1] != "summary" {
+			writeAPIError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		appStats, err := store.lastRun()
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		summary := orgSummary{Org: parts[0]}
+		spaces := map[string]bool{}
+		for _, app := range appStats {
+			if app.Org != parts[0] {
+				continue
+			}
+			summary.Apps++
+			summary.MemoryAlloc += app.MemoryAlloc * app.Instances
+			summary.AvgMemoryUse += app.AvgMemoryUse * app.Instances
+			summary.Waste += app.Waste()
+			summary.Savings += app.Savings()
+			if !spaces[app.SpaceName] {
+				spaces[app.SpaceName] = true
+				summary.Spaces = append(summary.Spaces, app.SpaceName)
+			}
+		}
+
+		if summary.Apps == 0 {
+			writeAPIError(w, http.StatusNotFound, "no apps for org "+parts[0])
+			return
+		}
+		sort.Strings(summary.Spaces)
+		writeAPIResponse(w, summary)
+	})
+}
+
+func writeAPIResponse(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/danhigham/hall-of-shame/pkg/collector"
+)
+
+// apiUsageStats counts the Cloud Controller calls made through cf curl and
+// keeps the most recent X-RateLimit headers seen.
+type apiUsageStats struct {
+	mutex     sync.Mutex
+	calls     int
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+var apiUsage = &apiUsageStats{remaining: -1}
+
+type (
+	rateLimitError = collector.RateLimitError
+	forbiddenError = collector.ForbiddenError
+)
+
+func (usage *apiUsageStats) record(header http.Header) {
+	usage.mutex.Lock()
+	defer usage.mutex.Unlock()
+
+	usage.calls++
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		usage.limit = limit
+	}
+	if remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		usage.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		usage.reset = time.Unix(reset, 0)
+	}
+}
+
+// summary prints the API cost of the run and resets the call count, so each
+// daemon collection reports its own.
+func (usage *apiUsageStats) summary(w io.Writer) {
+	usage.mutex.Lock()
+	defer usage.mutex.Unlock()
+
+	if usage.calls == 0 {
+		return
+	}
+	fmt.Fprintf(w, "API calls: %d", usage.calls)
+	if usage.remaining >= 0 {
+		fmt.Fprintf(w, ", rate limit remaining: %d/%d", usage.remaining, usage.limit)
+		if !usage.reset.IsZero() {
+			fmt.Fprintf(w, " (resets %s)", usage.reset.Local().Format("15:04"))
+		}
+	}
+	fmt.Fprintln(w)
+	usage.calls = 0
+}
+
+// meteredConnection adds -i to every cf curl so response headers can be
+// inspected, then hands callers the body as if they had asked for it alone.
+type meteredConnection struct {
+	plugin.CliConnection
+}
+
+func (connection meteredConnection) CliCommandWithoutTerminalOutput(args ...string) ([]string, error) {
+
+	if len(args) == 0 || args[0] != "curl" {
+		return connection.CliConnection.CliCommandWithoutTerminalOutput(args...)
+	}
+
+	withHeaders := containsString(args, "-i")
+	if !withHeaders {
+		args = append([]string{"curl", "-i"}, args[1:]...)
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err := connection.CliConnection.CliCommandWithoutTerminalOutput(args...)
+		if err != nil {
+			return output, err
+		}
+
+		status, header, body := splitCurlResponse(output)
+		apiUsage.record(header)
+
+		// Only the worker that hit the limit waits; the others carry on
+		// until they are throttled too.
+		if status == "429" {
+			if attempt == collector.MaxRateLimitRetries {
+				return nil, rateLimitError{Path: curlPath(args), Attempts: attempt}
+			}
+			wait := collector.RetryAfter(header.Get("Retry-After"))
+			fmt.Fprintf(progressOutput, "Rate limited by Cloud Controller, retrying in %s\n", wait)
+			time.Sleep(wait)
+			continue
+		}
+		if status == "403" {
+			return nil, forbiddenError{Path: curlPath(args)}
+		}
+
+		if withHeaders {
+			return output, nil
+		}
+		return []string{body}, nil
+	}
+}
+
+// curlPath finds the request path among cf curl's arguments.
+func curlPath(args []string) string {
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "-H", "-X", "-d", "--output":
+			i++
+		default:
+			if !strings.HasPrefix(args[i], "-") {
+				return args[i]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+// autoscalerOfferings are the service offering names App Autoscaler is
+// registered under across open source and commercial foundations.
+var autoscalerOfferings = []string{"app-autoscaler", "autoscaler"}
+
+type V3ResourceSearchResults struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []struct {
+		Guid          string    `json:"guid"`
+		Type          string    `json:"type"`
+		State         string    `json:"state"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
+		MemoryInMb    int       `json:"memory_in_mb"`
+		Relationships struct {
+			App struct {
+				Data struct {
+					Guid string `json:"guid"`
+				} `json:"data"`
+			} `json:"app"`
+		} `json:"relationships"`
+	} `json:"resources"`
+}
+
+func (hallOfShame *HallOfShame) v3Resources(cliConnection plugin.CliConnection, query string) (*V3ResourceSearchResults, error) {
+
+	all := &V3ResourceSearchResults{}
+	for query != "" {
+		output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+		if err != nil {
+			return nil, err
+		}
+
+		res := V3ResourceSearchResults{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			return nil, err
+		}
+		all.Resources = append(all.Resources, res.Resources...)
+
+		query = ""
+		if res.Pagination.Next != nil {
+			query = v3RequestPath(res.Pagination.Next.Href)
+		}
+	}
+
+	return all, nil
+}
+
+func (hallOfShame *HallOfShame) GetAutoscaledApps(cliConnection plugin.CliConnection) (map[string]bool, error) {
+
+	autoscaled := map[string]bool{}
+
+	offerings, err := hallOfShame.v3Resources(cliConnection, "/v3/service_offerings?per_page=5000&names="+strings.Join(autoscalerOfferings, ","))
+	if err != nil {
+		return nil, err
+	}
+	if len(offerings.Resources) == 0 {
+		return autoscaled, nil
+	}
+
+	var guids []string
+	for _, offering := range offerings.Resources {
+		guids = append(guids, offering.Guid)
+	}
+
+	bindings, err := hallOfShame.v3Resources(cliConnection, "/v3/service_credential_bindings?type=app&per_page=5000&service_offering_guids="+strings.Join(guids, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, binding := range bindings.Resources {
+		autoscaled[binding.Relationships.App.Data.Guid] = true
+	}
+
+	return autoscaled, nil
+}
+
+func (hallOfShame *HallOfShame) markAutoscaled(cliConnection plugin.CliConnection, appStats []appStatSummary) error {
+
+	autoscaled, err := hallOfShame.GetAutoscaledApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	for i := range appStats {
+		appStats[i].Autoscaled = autoscaled[appStats[i].GUID]
+	}
+
+	return nil
+}
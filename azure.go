@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const azureStorageVersion = "2020-10-02"
+
+type azureUploader struct {
+	account    string
+	container  string
+	accountKey string
+	sasToken   string
+}
+
+func newAzureUploader(opts *options) uploader {
+	return &azureUploader{
+		account:    opts.azureAccount,
+		container:  opts.azureContainer,
+		accountKey: os.Getenv("AZURE_STORAGE_KEY"),
+		sasToken:   strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+	}
+}
+
+func (uploader *azureUploader) Upload(key string, body []byte, contentType string) error {
+
+	resource := "/" + uploader.container + "/" + escapeObjectKey(key)
+	url := fmt.Sprintf("https://%s.blob.core.windows.net%s", uploader.account, resource)
+	if uploader.accountKey == "" {
+		url += "?" + uploader.sasToken
+	}
+
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureStorageVersion)
+
+	if uploader.accountKey != "" {
+		signature, err := uploader.sign(req, resource, len(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", uploader.account, signature))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("azure upload of %s failed: %s %s", key, resp.Status, msg)
+	}
+
+	return nil
+}
+
+func (uploader *azureUploader) sign(req *http.Request, resource string, length int) (string, error) {
+
+	key, err := base64.StdEncoding.DecodeString(uploader.accountKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid $AZURE_STORAGE_KEY: %v", err)
+	}
+
+	contentLength := ""
+	if length > 0 {
+		contentLength = strconv.Itoa(length)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower+":"+strings.TrimSpace(req.Header.Get(name)))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		"", // Content-Encoding
+		"", // Content-Language
+		contentLength,
+		"", // Content-MD5
+		req.Header.Get("Content-Type"),
+		"", // Date
+		"", // If-Modified-Since
+		"", // If-Match
+		"", // If-None-Match
+		"", // If-Unmodified-Since
+		"", // Range
+		strings.Join(msHeaders, "\n"),
+		"/" + uploader.account + resource,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func checkBaseline(w io.Writer, opts *options, appStats []appStatSummary) (bool, error) {
+
+	if opts.updateBaseline {
+		if err := saveSnapshot(opts.baseline, appStats); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(w, "Baseline %s updated (%d apps).\n", opts.baseline, len(appStats))
+		return false, nil
+	}
+
+	snap, err := loadSnapshot(opts.baseline)
+	if err != nil {
+		return false, err
+	}
+
+	accepted := map[string]int{}
+	var baselineWaste int
+	for _, app := range snap.Apps {
+		accepted[app.GUID] = app.Waste()
+		baselineWaste += app.Waste()
+	}
+
+	var currentWaste int
+	var regressions []appStatSummary
+	for _, app := range appStats {
+		currentWaste += app.Waste()
+		if app.Waste() > accepted[app.GUID] {
+			regressions = append(regressions, app)
+		}
+	}
+
+	if currentWaste <= baselineWaste {
+		fmt.Fprintf(w, "Waste %s is within the baseline of %s.\n", formatSize(currentWaste), formatSize(baselineWaste))
+		return false, nil
+	}
+
+	fmt.Fprintf(w, "Waste regressed from %s to %s (%s).\n", formatSize(baselineWaste), formatSize(currentWaste), formatSignedSize(currentWaste-baselineWaste))
+	for _, app := range regressions {
+		fmt.Fprintf(w, "  %s (%s): %s -> %s\n", app.Name, app.Space, formatSize(accepted[app.GUID]), formatSize(app.Waste()))
+	}
+
+	return true, nil
+}
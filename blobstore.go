@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/danhigham/hall-of-shame/pkg/collector"
+	"github.com/olekukonko/tablewriter"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+type blobSummary struct {
+	Name        string
+	Space       string
+	DropletSize int64
+	OldPackages int
+	OldBytes    int64
+}
+
+type blobClient struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func newBlobClient(cliConnection plugin.CliConnection) (*blobClient, error) {
+
+	api, err := cliConnection.ApiEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := cliConnection.AccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	insecure, _ := cliConnection.IsSSLDisabled()
+
+	return &blobClient{endpoint: strings.TrimSuffix(api, "/"), token: token, client: collector.NewClient(insecure)}, nil
+}
+
+// size reads the Content-Length of a droplet or package download. The Cloud
+// Controller redirects downloads to the blobstore, so the size comes from a
+// HEAD against the signed URL rather than the API itself.
+func (client *blobClient) size(path string) (int64, error) {
+
+	req, err := http.NewRequest("GET", client.endpoint+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", client.token)
+
+	noRedirect := *client.client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		return resp.ContentLength, nil
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return 0, fmt.Errorf("download of %s failed: %s", path, resp.Status)
+	}
+
+	head, err := client.client.Head(location)
+	if err != nil {
+		return 0, err
+	}
+	head.Body.Close()
+
+	if head.StatusCode >= 300 {
+		return 0, fmt.Errorf("blobstore HEAD for %s failed: %s", path, head.Status)
+	}
+	return head.ContentLength, nil
+}
+
+type V3CurrentDroplet struct {
+	Guid string `json:"guid"`
+}
+
+func (hallOfShame *HallOfShame) GetCurrentDroplet(cliConnection plugin.CliConnection, appGuid string) (string, error) {
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", fmt.Sprintf("/v3/apps/%v/droplets/current", appGuid))
+	if err != nil {
+		return "", err
+	}
+
+	res := V3CurrentDroplet{}
+	if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+		return "", err
+	}
+
+	return res.Guid, nil
+}
+
+func (hallOfShame *HallOfShame) collectBlobs(cliConnection plugin.CliConnection, client *blobClient, cfApp *AppSearchResoures) *blobSummary {
+
+	summary := &blobSummary{Name: cfApp.Entity.Name, Space: cfApp.Entity.SpaceGuid}
+
+	if droplet, err := hallOfShame.GetCurrentDroplet(cliConnection, cfApp.Metadata.Guid); err == nil && droplet != "" {
+		if size, err := client.size(fmt.Sprintf("/v3/droplets/%v/download", droplet)); err == nil {
+			summary.DropletSize = size
+		}
+	}
+
+	packages, err := hallOfShame.v3Resources(cliConnection, fmt.Sprintf("/v3/packages?app_guids=%v&types=bits&states=READY&per_page=5000", cfApp.Metadata.Guid))
+	if err != nil {
+		return summary
+	}
+
+	sort.Slice(packages.Resources, func(i, j int) bool {
+		return packages.Resources[i].CreatedAt.After(packages.Resources[j].CreatedAt)
+	})
+
+	for i, pkg := range packages.Resources {
+		if i == 0 {
+			continue
+		}
+		summary.OldPackages++
+		if size, err := client.size(fmt.Sprintf("/v3/packages/%v/download", pkg.Guid)); err == nil {
+			summary.OldBytes += size
+		}
+	}
+
+	return summary
+}
+
+func (hallOfShame *HallOfShame) runBlobstoreCommand(cliConnection plugin.CliConnection) error {
+
+	client, err := newBlobClient(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	var apps []*blobSummary
+	var mutex sync.Mutex
+
+	bar := newProgressBar(len(res.Resources))
+	bar.Start()
+
+	wg := sizedwaitgroup.New(2)
+	for _, app := range res.Resources {
+		wg.Add()
+
+		go func(cfApp *AppSearchResoures) {
+			defer wg.Done()
+
+			summary := hallOfShame.collectBlobs(cliConnection, client, cfApp)
+			bar.Increment()
+
+			mutex.Lock()
+			apps = append(apps, summary)
+			mutex.Unlock()
+		}(app)
+	}
+
+	wg.Wait()
+	bar.FinishPrint("Done!")
+
+	renderBlobs(os.Stdout, apps)
+	return nil
+}
+
+func renderBlobs(w io.Writer, apps []*blobSummary) {
+
+	sort.Slice(apps, func(i, j int) bool { return apps[i].DropletSize > apps[j].DropletSize })
+
+	fmt.Fprintln(w, "Largest current droplets:")
+	droplets := tablewriter.NewWriter(w)
+	droplets.SetHeader([]string{"Name", "Space", "Droplet"})
+	var dropletTotal int64
+	for _, app := range apps {
+		if app.DropletSize == 0 {
+			continue
+		}
+		droplets.Append([]string{app.Name, app.Space, formatSize(int(app.DropletSize))})
+		dropletTotal += app.DropletSize
+	}
+	droplets.Render()
+
+	sort.Slice(apps, func(i, j int) bool { return apps[i].OldBytes > apps[j].OldBytes })
+
+	fmt.Fprintln(w, "\nBytes held in superseded packages:")
+	packages := tablewriter.NewWriter(w)
+	packages.SetHeader([]string{"Name", "Space", "Old Packages", "Old Bytes"})
+	var packageTotal int64
+	for _, app := range apps {
+		if app.OldPackages == 0 {
+			continue
+		}
+		packages.Append([]string{app.Name, app.Space, fmt.Sprintf("%d", app.OldPackages), formatSize(int(app.OldBytes))})
+		packageTotal += app.OldBytes
+	}
+	packages.Render()
+
+	fmt.Fprintf(w, "\nCurrent droplets total %s; superseded packages hold another %s.\n", formatSize(int(dropletTotal)), formatSize(int(packageTotal)))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danhigham/hall-of-shame/pkg/collector"
+)
+
+func TestBlobClientSizeFollowsRedirectOverSelfSignedTLS(t *testing.T) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/droplets/d1/download", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://"+r.Host+"/blobs/d1", http.StatusFound)
+	})
+	mux.HandleFunc("/blobs/d1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			t.Errorf("blobstore got %s, want HEAD", r.Method)
+		}
+		w.Header().Set("Content-Length", "4096")
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	client := &blobClient{endpoint: server.URL, token: "bearer token", client: collector.NewClient(true)}
+	size, err := client.size("/v3/droplets/d1/download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 4096 {
+		t.Errorf("size = %d, want 4096", size)
+	}
+}
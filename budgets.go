@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+type orgBudget struct {
+	Memory string  `json:"memory"`
+	Cost   float64 `json:"cost"`
+}
+
+type budgetStatus struct {
+	Org          string
+	Allocated    int
+	MemoryBudget int
+	MonthlyCost  float64
+	CostBudget   float64
+}
+
+func (status *budgetStatus) exceeded() bool {
+	return (status.MemoryBudget > 0 && status.Allocated > status.MemoryBudget) ||
+		(status.CostBudget > 0 && status.MonthlyCost > status.CostBudget)
+}
+
+// checkBudgets totals each org's allocation, so it expects every collected
+// app rather than the filtered report. Stopped apps hold no memory and are
+// left out.
+func checkBudgets(opts *options, appStats []appStatSummary) ([]budgetStatus, error) {
+
+	data, err := ioutil.ReadFile(opts.orgBudgets)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateConfig(opts.orgBudgets, data, budgetsSchema); err != nil {
+		return nil, err
+	}
+
+	budgets := map[string]orgBudget{}
+	if err := json.Unmarshal(data, &budgets); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", opts.orgBudgets, err)
+	}
+
+	allocated := map[string]int{}
+	cost := map[string]float64{}
+	for i := range appStats {
+		app := &appStats[i]
+		if app.State == "STOPPED" {
+			continue
+		}
+		allocated[app.Org] += app.MemoryAlloc * app.Instances
+		cost[app.Org] += costRates.forSegment(app.IsolationSegment).monthlyCost(app)
+	}
+
+	var statuses []budgetStatus
+	for org, budget := range budgets {
+		status := budgetStatus{Org: org, Allocated: allocated[org], CostBudget: budget.Cost}
+
+		if budget.Memory != "" {
+			if status.MemoryBudget, err = parseSize(budget.Memory); err != nil {
+				return nil, fmt.Errorf("budget for %s: %v", org, err)
+			}
+		}
+		if budget.Cost > 0 {
+			if !costRates.configured() {
+				return nil, fmt.Errorf("cost budget for %s requires --rate-gb-hour or --rates", org)
+			}
+			status.MonthlyCost = cost[org]
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Org < statuses[j].Org })
+	return statuses, nil
+}
+
+func renderBudgets(w io.Writer, statuses []budgetStatus, currency string) {
+
+	fmt.Fprintln(w, "\nOrg budgets:")
+	for _, status := range statuses {
+		mark := "ok"
+		if status.exceeded() {
+			mark = "OVER BUDGET"
+		}
+
+		line := fmt.Sprintf("  %-30s %s allocated", status.Org, formatSize(status.Allocated))
+		if status.MemoryBudget > 0 {
+			line += fmt.Sprintf(" / %s budget", formatSize(status.MemoryBudget))
+		}
+		if status.CostBudget > 0 {
+			line += fmt.Sprintf(", %.2f / %.2f %s per month", status.MonthlyCost, status.CostBudget, currency)
+		}
+		fmt.Fprintf(w, "%s  [%s]\n", line, mark)
+	}
+}
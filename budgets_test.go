@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckBudgetsSkipsStoppedApps(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "budgets.json")
+	if err := ioutil.WriteFile(path, []byte(`{"acme": {"memory": "4G"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	appStats := []appStatSummary{
+		{Name: "api", Org: "acme", State: "RUNNING", Instances: 2, MemoryAlloc: 1 << 30},
+		{Name: "parked", Org: "acme", State: "STOPPED", Instances: 4, MemoryAlloc: 1 << 30},
+	}
+
+	statuses, err := checkBudgets(&options{orgBudgets: path}, appStats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].Allocated != 2<<30 {
+		t.Fatalf("expected 2G allocated to acme, got %+v", statuses)
+	}
+	if statuses[0].exceeded() {
+		t.Errorf("acme should be within its budget once the stopped app is left out")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+// platformCapacity is the foundation-wide Diego cell memory, in bytes.
+type platformCapacity struct {
+	Cells     int
+	Total     int
+	Remaining int
+}
+
+// fetchPlatformCapacity sums the rep's capacity gauges from Log Cache. Only
+// admins can read the rep's metrics.
+func fetchPlatformCapacity(cliConnection plugin.CliConnection) (*platformCapacity, error) {
+
+	client, err := newLogCacheClient(cliConnection)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := &platformCapacity{}
+	for query, target := range map[string]*int{
+		`count(CapacityTotalMemory{source_id="rep"})`:   &capacity.Cells,
+		`sum(CapacityTotalMemory{source_id="rep"})`:     &capacity.Total,
+		`sum(CapacityRemainingMemory{source_id="rep"})`: &capacity.Remaining,
+	} {
+		value, err := client.instantQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		*target = int(value)
+	}
+	if capacity.Total == 0 {
+		return nil, fmt.Errorf("no cell capacity metrics in log cache")
+	}
+
+	// The rep reports memory in MiB.
+	capacity.Total <<= 20
+	capacity.Remaining <<= 20
+	return capacity, nil
+}
+
+func (client *logCacheClient) instantQuery(promQL string) (float64, error) {
+
+	query := url.Values{}
+	query.Set("query", promQL)
+
+	body, err := client.get("/api/v1/query", query)
+	if err != nil {
+		return 0, err
+	}
+
+	res := logCacheQueryResult{}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return 0, err
+	}
+	if len(res.Data.Result) == 0 || len(res.Data.Result[0].Value) < 2 {
+		return 0, nil
+	}
+
+	value, ok := res.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected log cache value for %s", promQL)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// renderCapacity puts the report's allocation and use next to the size of
+// the platform, so the waste figures have a denominator.
+func renderCapacity(w io.Writer, capacity *platformCapacity, appStats []appStatSummary) {
+
+	var allocated, used, waste int
+	for _, app := range appStats {
+		allocated += app.MemoryAlloc * app.Instances
+		used += app.AvgMemoryUse * app.Instances
+		waste += app.Waste()
+	}
+
+	percent := func(size int) string {
+		return fmt.Sprintf("%.1f%%", float64(size)/float64(capacity.Total)*100)
+	}
+
+	platformAllocated := capacity.Total - capacity.Remaining
+	fmt.Fprintf(w, "\nPlatform capacity: %s across %d cells, %s (%s) allocated\n",
+		formatSize(capacity.Total), capacity.Cells, formatSize(platformAllocated), percent(platformAllocated))
+	fmt.Fprintf(w, "This report: %s allocated (%s), %s used (%s), %s wasted (%s of capacity)\n",
+		formatSize(allocated), percent(allocated), formatSize(used), percent(used), formatSize(waste), percent(waste))
+}
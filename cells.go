@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+type cellSummary struct {
+	Host      string
+	Instances int
+	Apps      map[string]bool
+	Allocated int
+	Used      int
+}
+
+func (hallOfShame *HallOfShame) forEachAppStats(cliConnection plugin.CliConnection, visit func(*AppSearchResoures, map[string]AppStat)) error {
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	var mutex sync.Mutex
+
+	bar := newProgressBar(len(res.Resources))
+	bar.Start()
+
+	wg := sizedwaitgroup.New(2)
+	for _, app := range res.Resources {
+		wg.Add()
+
+		go func(cfApp *AppSearchResoures) {
+			defer wg.Done()
+
+			stats, err := hallOfShame.GetAppStats(cliConnection, cfApp.Metadata.Guid)
+			bar.Increment()
+			if err != nil {
+				return
+			}
+
+			mutex.Lock()
+			visit(cfApp, stats)
+			mutex.Unlock()
+		}(app)
+	}
+
+	wg.Wait()
+	bar.FinishPrint("Done!")
+
+	return nil
+}
+
+func (hallOfShame *HallOfShame) runCellsCommand(cliConnection plugin.CliConnection) error {
+
+	cells := map[string]*cellSummary{}
+
+	err := hallOfShame.forEachAppStats(cliConnection, func(cfApp *AppSearchResoures, stats map[string]AppStat) {
+		for _, stat := range stats {
+			if stat.State != "RUNNING" || stat.Stats.Host == "" {
+				continue
+			}
+
+			cell, ok := cells[stat.Stats.Host]
+			if !ok {
+				cell = &cellSummary{Host: stat.Stats.Host, Apps: map[string]bool{}}
+				cells[stat.Stats.Host] = cell
+			}
+			cell.Instances++
+			cell.Apps[cfApp.Metadata.Guid] = true
+			cell.Allocated += stat.Stats.MemQuota
+			cell.Used += stat.Stats.Usage.Mem
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	renderCells(os.Stdout, cells)
+	return nil
+}
+
+func renderCells(w io.Writer, cells map[string]*cellSummary) {
+
+	var hosts []*cellSummary
+	for _, cell := range cells {
+		hosts = append(hosts, cell)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Allocated > hosts[j].Allocated })
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Cell", "Instances", "Apps", "Alloc", "Used", "Used%"})
+
+	var instances, allocated, used int
+	for _, cell := range hosts {
+		usedPct := 0.0
+		if cell.Allocated > 0 {
+			usedPct = float64(cell.Used) / float64(cell.Allocated) * 100
+		}
+		table.Append([]string{
+			cell.Host,
+			fmt.Sprintf("%d", cell.Instances),
+			fmt.Sprintf("%d", len(cell.Apps)),
+			formatSize(cell.Allocated),
+			formatSize(cell.Used),
+			fmt.Sprintf("%.0f%%", usedPct),
+		})
+		instances += cell.Instances
+		allocated += cell.Allocated
+		used += cell.Used
+	}
+
+	table.SetFooter([]string{fmt.Sprintf("%d cells", len(hosts)), fmt.Sprintf("%d", instances), "", formatSize(allocated), formatSize(used), ""})
+	table.Render()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const chartWidth = 40
+
+type chartBar struct {
+	label string
+	value int
+}
+
+// renderWasteChart draws wasted memory per org, or per app for the top N
+// apps when top is set.
+func renderWasteChart(w io.Writer, appStats []appStatSummary, top int) {
+
+	var bars []chartBar
+	title := "Wasted memory per org"
+
+	if top > 0 {
+		title = fmt.Sprintf("Wasted memory, top %d apps", top)
+		for _, app := range appStats {
+			bars = append(bars, chartBar{app.Name, app.Waste()})
+		}
+	} else {
+		byOrg := map[string]int{}
+		for _, app := range appStats {
+			byOrg[app.Org] += app.Waste()
+		}
+		for org, waste := range byOrg {
+			bars = append(bars, chartBar{org, waste})
+		}
+	}
+
+	sort.Slice(bars, func(i, j int) bool { return bars[i].value > bars[j].value })
+	if top > 0 && len(bars) > top {
+		bars = bars[:top]
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	renderBars(w, bars)
+}
+
+func renderBars(w io.Writer, bars []chartBar) {
+
+	var max, labelWidth int
+	for _, bar := range bars {
+		if bar.value > max {
+			max = bar.value
+		}
+		if width := utf8.RuneCountInString(bar.label); width > labelWidth {
+			labelWidth = width
+		}
+	}
+	if max == 0 {
+		return
+	}
+
+	for _, bar := range bars {
+		padding := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(bar.label))
+		fmt.Fprintf(w, "  %s%s %s %s\n", bar.label, padding, barOf(float64(bar.value)/float64(max), chartWidth), formatSize(bar.value))
+	}
+}
+
+// barOf renders a fraction of width using eighth-block characters so small
+// differences still show.
+func barOf(fraction float64, width int) string {
+	eighths := int(fraction*float64(width*8) + 0.5)
+	bar := strings.Repeat("█", eighths/8)
+	if partial := eighths % 8; partial > 0 {
+		bar += string([]rune("▏▎▍▌▋▊▉")[partial-1])
+	}
+	return bar
+}
+
+// renderAllocationBreakdown shows each org's share of platform allocation as
+// a proportional bar, solid for memory in use and shaded for waste.
+func renderAllocationBreakdown(w io.Writer, appStats []appStatSummary) {
+
+	type orgShare struct {
+		org       string
+		allocated int
+		waste     int
+	}
+
+	byOrg := map[string]*orgShare{}
+	var total int
+	for _, app := range appStats {
+		share, ok := byOrg[app.Org]
+		if !ok {
+			share = &orgShare{org: app.Org}
+			byOrg[app.Org] = share
+		}
+		share.allocated += app.MemoryAlloc * app.Instances
+		share.waste += app.Waste()
+		total += app.MemoryAlloc * app.Instances
+	}
+	if total == 0 {
+		return
+	}
+
+	var shares []*orgShare
+	var labelWidth int
+	for _, share := range byOrg {
+		shares = append(shares, share)
+		if width := utf8.RuneCountInString(share.org); width > labelWidth {
+			labelWidth = width
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].allocated > shares[j].allocated })
+
+	fmt.Fprintf(w, "\nShare of %s allocated (█ in use, ░ wasted):\n", formatSize(total))
+	for _, share := range shares {
+		cells := int(float64(share.allocated)/float64(total)*chartWidth + 0.5)
+		wasted := 0
+		if share.allocated > 0 {
+			wasted = int(float64(share.waste)/float64(share.allocated)*float64(cells) + 0.5)
+		}
+		bar := strings.Repeat("█", cells-wasted) + strings.Repeat("░", wasted) + strings.Repeat(" ", chartWidth-cells)
+		padding := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(share.org))
+		fmt.Fprintf(w, "  %s%s %s %5.1f%%  (%s, %s wasted)\n", share.org, padding, bar,
+			float64(share.allocated)/float64(total)*100, formatSize(share.allocated), formatSize(share.waste))
+	}
+}
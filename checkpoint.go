@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+type checkpointEntry struct {
+	GUID string           `json:"guid"`
+	Rows []appStatSummary `json:"rows"`
+}
+
+// scanCheckpoint appends each finished app to --checkpoint as a JSON line, so
+// an interrupted scan can be picked up again with --resume.
+type scanCheckpoint struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+	done  map[string][]appStatSummary
+}
+
+func openCheckpoint(opts *options) (*scanCheckpoint, error) {
+
+	checkpoint := &scanCheckpoint{path: opts.checkpoint, done: map[string][]appStatSummary{}}
+	if opts.checkpoint == "" {
+		return checkpoint, nil
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if opts.resume {
+		if err := checkpoint.load(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(progressOutput, "Resuming scan, %d apps already done\n", len(checkpoint.done))
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(opts.checkpoint, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint.file = file
+	return checkpoint, nil
+}
+
+func (checkpoint *scanCheckpoint) load() error {
+
+	file, err := os.Open(checkpoint.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		entry := checkpointEntry{}
+		// A line cut short by the interruption is simply scanned again.
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		checkpoint.done[entry.GUID] = entry.Rows
+	}
+	return scanner.Err()
+}
+
+// completed returns the rows recorded for an app by the interrupted run.
+func (checkpoint *scanCheckpoint) completed(guid string) ([]appStatSummary, bool) {
+	rows, ok := checkpoint.done[guid]
+	return rows, ok
+}
+
+func (checkpoint *scanCheckpoint) record(guid string, rows []appStatSummary) {
+
+	if checkpoint.file == nil {
+		return
+	}
+
+	data, err := json.Marshal(checkpointEntry{GUID: guid, Rows: rows})
+	if err != nil {
+		return
+	}
+
+	checkpoint.mutex.Lock()
+	defer checkpoint.mutex.Unlock()
+	checkpoint.file.Write(append(data, '\n'))
+}
+
+// finish removes the checkpoint once the scan has completed.
+func (checkpoint *scanCheckpoint) finish() error {
+	if checkpoint.file == nil {
+		return nil
+	}
+	checkpoint.file.Close()
+	return os.Remove(checkpoint.path)
+}
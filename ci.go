@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Exit codes for --ci, so pipelines can tell a broken run from a policy
+// failure without parsing output.
+const (
+	ciExitPass       = 0
+	ciExitError      = 1
+	ciExitPolicy     = 2
+	ciExitRegression = 3
+)
+
+type ciSummary struct {
+	Status     string   `json:"status"`
+	Apps       int      `json:"apps"`
+	Offenders  int      `json:"offenders"`
+	Allocated  int      `json:"allocated_bytes"`
+	Waste      int      `json:"waste_bytes"`
+	Savings    int      `json:"savings_bytes"`
+	Violations []string `json:"violations,omitempty"`
+	Regressed  bool     `json:"regressed"`
+	Report     string   `json:"report,omitempty"`
+}
+
+func writeCIReport(dir string, appStats []appStatSummary) (string, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "hall-of-shame.json")
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return path, renderJSON(file, appStats)
+}
+
+func runCI(w io.Writer, opts *options, appStats []appStatSummary) int {
+
+	summary := ciSummary{Status: "pass", Apps: len(appStats)}
+	for i := range appStats {
+		app := &appStats[i]
+		summary.Allocated += app.MemoryAlloc * app.Instances
+		summary.Waste += app.Waste()
+		summary.Savings += app.Savings()
+		if app.Ratio > opts.offenderRatio {
+			summary.Offenders++
+		}
+	}
+
+	if opts.ciOutput != "" {
+		path, err := writeCIReport(opts.ciOutput, appStats)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ciExitError
+		}
+		summary.Report = path
+	}
+
+	if opts.baseline != "" {
+		regressed, err := checkBaseline(ioutil.Discard, opts, appStats)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ciExitError
+		}
+		summary.Regressed = regressed
+	}
+
+	if opts.failOnPolicy != nil {
+		for _, app := range policyViolations(opts.failOnPolicy, appStats) {
+			summary.Violations = append(summary.Violations, app.Org+"/"+app.SpaceName+"/"+processAppName(app))
+		}
+	}
+
+	if opts.regoPolicy != "" {
+		results, err := evaluateRego(opts, appStats)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ciExitError
+		}
+		renderRegoResults(os.Stderr, appStats, results)
+
+		denied := map[string]bool{}
+		for _, result := range results {
+			denied[result.key()] = denied[result.key()] || len(result.Deny) > 0
+		}
+		for _, app := range appStats {
+			if denied[app.Key()] {
+				summary.Violations = append(summary.Violations, app.Org+"/"+app.SpaceName+"/"+processAppName(app))
+			}
+		}
+	}
+
+	code := ciExitPass
+	switch {
+	case len(summary.Violations) > 0:
+		code = ciExitPolicy
+	case summary.Regressed && opts.failOnRegression:
+		code = ciExitRegression
+	}
+	if code != ciExitPass {
+		summary.Status = "fail"
+	}
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(summary); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ciExitError
+	}
+
+	return code
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/danhigham/hall-of-shame/pkg/collector"
+)
+
+// CloudController is the part of the Cloud Controller API the collection
+// loop depends on. Tests can set HallOfShame.controller to a fake.
+type CloudController = collector.CloudController
+
+func (hallOfShame *HallOfShame) cloudController(cliConnection plugin.CliConnection) CloudController {
+	if hallOfShame.controller != nil {
+		return hallOfShame.controller
+	}
+	return cfCurlController{cliConnection: cliConnection}
+}
+
+func (hallOfShame *HallOfShame) GetAppStats(cliConnection plugin.CliConnection, appGuid string) (map[string]AppStat, error) {
+	return hallOfShame.cloudController(cliConnection).GetAppStats(appGuid)
+}
+
+func (hallOfShame *HallOfShame) GetAllApps(cliConnection plugin.CliConnection) (AppSearchResults, error) {
+	return hallOfShame.cloudController(cliConnection).GetAllApps()
+}
+
+// cfCurlController goes through cf curl, and so through --fixture, --record,
+// --replay, ETags and API call metering.
+type cfCurlController struct {
+	cliConnection plugin.CliConnection
+}
+
+func (controller cfCurlController) GetAppStats(appGuid string) (map[string]AppStat, error) {
+
+	appQuery := fmt.Sprintf("/v2/apps/%v/stats", appGuid)
+
+	output, err := conditionalRequests.curl(controller.cliConnection, appQuery)
+	switch err.(type) {
+	case rateLimitError, forbiddenError:
+		return nil, err
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := json.Compact(buffer, []byte(strings.Join(output, ""))); err != nil {
+		fmt.Println(err)
+	}
+
+	// fmt.Printf("********\n%s\n\n%+v\n*********\n\n", appQuery, buffer)
+	statResult := map[string]AppStat{}
+	err = json.Unmarshal([]byte(strings.Join(output, "")), &statResult)
+
+	return statResult, err
+}
+
+func (controller cfCurlController) GetAllApps() (AppSearchResults, error) {
+
+	apps := AppSearchResults{}
+	for appQuery := collector.AllAppsPath; appQuery != ""; {
+		output, err := conditionalRequests.curl(controller.cliConnection, appQuery)
+		if err != nil {
+			return apps, err
+		}
+
+		res := AppSearchResults{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			return apps, fmt.Errorf("%s: %v", appQuery, err)
+		}
+		apps.Resources = append(apps.Resources, res.Resources...)
+		appQuery = res.NextUrl
+	}
+
+	return apps, nil
+}
+
+// newHTTPController talks to the Cloud Controller directly with the cf
+// CLI's token, skipping a cf curl subprocess per request.
+func newHTTPController(cliConnection plugin.CliConnection) (*collector.HTTPClient, error) {
+
+	api, err := cliConnection.ApiEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := cliConnection.AccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	insecure, _ := cliConnection.IsSSLDisabled()
+	controller := collector.NewHTTPClient(api, token, insecure)
+	controller.OnResponse = apiUsage.record
+	return controller, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// fakeController serves a fixed app list and per-app stats or errors.
+type fakeController struct {
+	apps   AppSearchResults
+	stats  map[string]map[string]AppStat
+	errors map[string]error
+}
+
+func (controller *fakeController) GetAllApps() (AppSearchResults, error) {
+	return controller.apps, nil
+}
+
+func (controller *fakeController) GetAppStats(appGuid string) (map[string]AppStat, error) {
+	if err, ok := controller.errors[appGuid]; ok {
+		return nil, err
+	}
+	return controller.stats[appGuid], nil
+}
+
+func fakeApp(guid string, name string, state string, memory int) *AppSearchResoures {
+	return &AppSearchResoures{
+		Metadata: &AppSearchMetaData{Guid: guid},
+		Entity:   &AppSearchEntity{Name: name, Instances: 2, SpaceGuid: "space-acme-prod", StackGuid: "stack-cflinuxfs4", State: state, Memory: memory},
+	}
+}
+
+func fakeStats(t *testing.T, data string) map[string]AppStat {
+	stats := map[string]AppStat{}
+	if err := json.Unmarshal([]byte(data), &stats); err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func TestCollectWithFakeController(t *testing.T) {
+
+	progressOutput = ioutil.Discard
+
+	// Names and stacks still come through cf curl, answered by the fixture;
+	// apps and stats come from the fake.
+	connection, err := loadFixture("testdata/run.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controller := &fakeController{
+		apps: AppSearchResults{Resources: []*AppSearchResoures{
+			fakeApp("app-busy", "busy", "STARTED", 1024),
+			fakeApp("app-locked", "locked", "STARTED", 1024),
+			fakeApp("app-parked", "parked", "STOPPED", 1024),
+		}},
+		stats: map[string]map[string]AppStat{
+			"app-busy": fakeStats(t, `{
+				"0": {"state": "RUNNING", "stats": {"mem_quota": 1073741824, "usage": {"mem": 268435456}}},
+				"1": {"state": "RUNNING", "stats": {"mem_quota": 1073741824, "usage": {"mem": 268435456}}}
+			}`),
+		},
+		errors: map[string]error{
+			"app-locked": forbiddenError{Path: "/v2/apps/app-locked/stats"},
+		},
+	}
+
+	opts, err := parseOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hallOfShame := &HallOfShame{controller: controller}
+	appStats, err := hallOfShame.collect(connection, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(appStats) != 1 {
+		t.Fatalf("collected %d apps, want only busy", len(appStats))
+	}
+	app := appStats[0]
+	if app.Name != "busy" || app.Ratio != 4 || app.MemoryAlloc != 1<<30 || app.AvgMemoryUse != 256<<20 {
+		t.Errorf("busy = %+v, want 1G allocated, 256M used, ratio 4", app)
+	}
+	if app.SpaceName != "prod" || app.Org != "acme" || app.Stack != "cflinuxfs4" {
+		t.Errorf("busy is in %s/%s on %s, want acme/prod on cflinuxfs4", app.Org, app.SpaceName, app.Stack)
+	}
+
+	denied := reportMeta.AccessDenied
+	if len(denied) != 1 || denied[0].GUID != "app-locked" {
+		t.Errorf("access denied = %+v, want locked", denied)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+type orgTotals struct {
+	Apps      int
+	Instances int
+	Allocated int
+	Used      int
+	Waste     int
+	Savings   int
+}
+
+func (totals *orgTotals) efficiency() float64 {
+	if totals.Allocated == 0 {
+		return 0
+	}
+	return float64(totals.Used) / float64(totals.Allocated) * 100
+}
+
+func (hallOfShame *HallOfShame) runCompareCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	if len(opts.orgs) < 2 {
+		return fmt.Errorf("usage: hall-of-shame compare --org A --org B [--org C ...]")
+	}
+
+	appStats, err := hallOfShame.collect(cliConnection, opts)
+	if err != nil {
+		return err
+	}
+
+	totals := map[string]*orgTotals{}
+	for _, org := range opts.orgs {
+		totals[org] = &orgTotals{}
+	}
+
+	for _, app := range applyFilters(opts, appStats) {
+		org := totals[app.Org]
+		org.Apps++
+		org.Instances += app.Instances
+		org.Allocated += app.MemoryAlloc * app.Instances
+		org.Used += app.AvgMemoryUse * app.Instances
+		org.Waste += app.Waste()
+		org.Savings += app.Savings()
+	}
+
+	renderComparison(os.Stdout, opts.orgs, totals)
+	return nil
+}
+
+func renderComparison(w io.Writer, orgs []string, totals map[string]*orgTotals) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(append([]string{""}, orgs...))
+
+	rows := []struct {
+		label string
+		value func(*orgTotals) string
+	}{
+		{"Apps", func(t *orgTotals) string { return fmt.Sprintf("%d", t.Apps) }},
+		{"Instances", func(t *orgTotals) string { return fmt.Sprintf("%d", t.Instances) }},
+		{"Allocated", func(t *orgTotals) string { return formatSize(t.Allocated) }},
+		{"Used", func(t *orgTotals) string { return formatSize(t.Used) }},
+		{"Waste", func(t *orgTotals) string { return formatSize(t.Waste) }},
+		{"Efficiency", func(t *orgTotals) string { return fmt.Sprintf("%.0f%%", t.efficiency()) }},
+		{"Projected Savings", func(t *orgTotals) string { return formatSize(t.Savings) }},
+	}
+
+	for _, row := range rows {
+		line := []string{row.label}
+		for _, org := range orgs {
+			line = append(line, row.value(totals[org]))
+		}
+		table.Append(line)
+	}
+
+	table.Render()
+}
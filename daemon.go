@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/robfig/cron/v3"
+)
+
+func (hallOfShame *HallOfShame) runDaemon(cliConnection plugin.CliConnection, opts *options) error {
+
+	if opts.schedule == "" {
+		return fmt.Errorf("daemon requires --schedule, e.g. --schedule \"0 6 * * MON\"")
+	}
+
+	schedule, err := cron.ParseStandard(opts.schedule)
+	if err != nil {
+		return fmt.Errorf("invalid --schedule %q: %v", opts.schedule, err)
+	}
+
+	opts.history = true
+	conditionalRequests.enabled = !opts.noETags
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	for {
+		next := schedule.Next(time.Now())
+		fmt.Printf("Next collection at %s\n", next.Format(time.RFC1123))
+
+		select {
+		case sig := <-signals:
+			fmt.Printf("Received %s, stopping.\n", sig)
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+
+		appStats, err := hallOfShame.collect(cliConnection, opts)
+		if err != nil {
+			fmt.Printf("Collection failed: %v\n", err)
+			continue
+		}
+
+		unfiltered := appStats
+		appStats = applyFilters(opts, appStats)
+		setAppsManagerLinks(opts, appStats)
+		sort.Stable(byRatio(appStats))
+		hallOfShame.publish(opts, appStats, unfiltered)
+
+		var waste int
+		for _, app := range appStats {
+			waste += app.Waste()
+		}
+		fmt.Printf("Collected %d apps, %s wasted.\n", len(appStats), formatSize(waste))
+		if requests, unchanged := conditionalRequests.stats(); unchanged > 0 {
+			fmt.Printf("%d of %d requests unchanged since the last collection.\n", unchanged, requests)
+		}
+		apiUsage.summary(os.Stdout)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+// dangerSummary is the under-provisioned counterpart of appStatSummary: how
+// close an app runs to its quota and how often it has gone over.
+type dangerSummary struct {
+	Name      string
+	GUID      string
+	SpaceGUID string
+	Space     string
+	Org       string
+	Instances int
+	Quota     int
+	Peak      int
+	OOMKills  int
+	LastOOM   time.Time
+}
+
+func (summary *dangerSummary) percent() float64 {
+	if summary.Quota == 0 {
+		return 0
+	}
+	return float64(summary.Peak) / float64(summary.Quota) * 100
+}
+
+// isOOMCrash recognises both the reason Diego sets for memory kills and the
+// exit description the v2 API reported before it.
+func isOOMCrash(reason string, exitDescription string) bool {
+	return strings.EqualFold(reason, "OUT_OF_MEMORY") || strings.Contains(strings.ToLower(exitDescription), "out of memory")
+}
+
+// GetOOMKills counts out-of-memory crashes per app over recentCrashWindow,
+// paging through every crash event the user can see.
+func (hallOfShame *HallOfShame) GetOOMKills(cliConnection plugin.CliConnection) (map[string][]time.Time, error) {
+
+	since := time.Now().Add(-recentCrashWindow).UTC().Format(time.RFC3339)
+	query := fmt.Sprintf("/v3/audit_events?types=audit.app.process.crash&created_ats%%5Bgt%%5D=%v&per_page=5000", url.QueryEscape(since))
+
+	kills := map[string][]time.Time{}
+	for query != "" {
+		output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+		if err != nil {
+			return nil, err
+		}
+
+		res := AuditEventResults{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			return nil, err
+		}
+
+		for _, event := range res.Resources {
+			if !isOOMCrash(event.Data.Reason, event.Data.ExitDescription) {
+				continue
+			}
+			at, _ := time.Parse(time.RFC3339, event.CreatedAt)
+			kills[event.Target.Guid] = append(kills[event.Target.Guid], at)
+		}
+
+		query = ""
+		if res.Pagination.Next != nil {
+			query = v3RequestPath(res.Pagination.Next.Href)
+		}
+	}
+
+	return kills, nil
+}
+
+// runDangerCommand lists apps that were OOM killed recently or whose busiest
+// instance is above --danger-threshold percent of its memory quota.
+func (hallOfShame *HallOfShame) runDangerCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	kills, err := hallOfShame.GetOOMKills(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	var apps []*dangerSummary
+	err = hallOfShame.forEachAppStats(cliConnection, func(cfApp *AppSearchResoures, stats map[string]AppStat) {
+		summary := &dangerSummary{Name: cfApp.Entity.Name, GUID: cfApp.Metadata.Guid, SpaceGUID: cfApp.Entity.SpaceGuid}
+
+		for _, stat := range stats {
+			if stat.State != "RUNNING" {
+				continue
+			}
+			summary.Instances++
+			summary.Quota = stat.Stats.MemQuota
+			if stat.Stats.Usage.Mem > summary.Peak {
+				summary.Peak = stat.Stats.Usage.Mem
+			}
+		}
+
+		for _, at := range kills[summary.GUID] {
+			summary.OOMKills++
+			if at.After(summary.LastOOM) {
+				summary.LastOOM = at
+			}
+		}
+
+		if summary.OOMKills > 0 || (summary.Instances > 0 && summary.percent() >= opts.dangerThreshold) {
+			apps = append(apps, summary)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		spaceNames := names.resolve(app.SpaceGUID)
+		app.Space, app.Org = spaceNames.space, spaceNames.org
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		if apps[i].OOMKills != apps[j].OOMKills {
+			return apps[i].OOMKills > apps[j].OOMKills
+		}
+		return apps[i].percent() > apps[j].percent()
+	})
+
+	renderDanger(os.Stdout, apps, opts.dangerThreshold)
+	return nil
+}
+
+func renderDanger(w io.Writer, apps []*dangerSummary, threshold float64) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Instances", "Quota", "Peak", "Peak%", "OOM Kills", "Last OOM"})
+
+	for _, app := range apps {
+		lastOOM := ""
+		if !app.LastOOM.IsZero() {
+			lastOOM = time.Since(app.LastOOM).Round(time.Minute).String() + " ago"
+		}
+		table.Append([]string{
+			app.Name,
+			app.Org,
+			app.Space,
+			fmt.Sprintf("%d", app.Instances),
+			formatSize(app.Quota),
+			formatSize(app.Peak),
+			fmt.Sprintf("%.0f%%", app.percent()),
+			fmt.Sprintf("%d", app.OOMKills),
+			lastOOM,
+		})
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d apps OOM killed in the last %d days or running at or above %.0f%% of their memory quota.\n",
+		len(apps), int(recentCrashWindow.Hours()/24), threshold)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+type appDelta struct {
+	App      appStatSummary
+	Previous *appStatSummary
+}
+
+type runDiff struct {
+	Apps         []appDelta
+	NewOffenders []appStatSummary
+	Fixed        []appStatSummary
+}
+
+func loadPrevious(opts *options, source string) ([]appStatSummary, error) {
+	if source != "last" {
+		snap, err := loadSnapshot(source)
+		if err != nil {
+			return nil, err
+		}
+		return snap.Apps, nil
+	}
+
+	if _, err := os.Stat(opts.historyDB); err != nil {
+		return nil, fmt.Errorf("--diff last needs a previous --history run: %v", err)
+	}
+
+	store, err := openHistory(opts.historyDB)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	return store.lastRun()
+}
+
+func diffRuns(previous []appStatSummary, current []appStatSummary, offenderRatio float64) runDiff {
+
+	before := map[string]appStatSummary{}
+	for _, app := range previous {
+		before[app.Key()] = app
+	}
+
+	diff := runDiff{}
+	seen := map[string]bool{}
+
+	for _, app := range current {
+		seen[app.Key()] = true
+		delta := appDelta{App: app}
+
+		prev, existed := before[app.Key()]
+		if existed {
+			delta.Previous = &prev
+		}
+
+		if app.Ratio > offenderRatio && (!existed || prev.Ratio <= offenderRatio) {
+			diff.NewOffenders = append(diff.NewOffenders, app)
+		}
+		if existed && prev.Ratio > offenderRatio && app.Ratio <= offenderRatio {
+			diff.Fixed = append(diff.Fixed, app)
+		}
+
+		diff.Apps = append(diff.Apps, delta)
+	}
+
+	for _, app := range previous {
+		if !seen[app.Key()] && app.Ratio > offenderRatio {
+			diff.Fixed = append(diff.Fixed, app)
+		}
+	}
+
+	return diff
+}
+
+func trendArrow(delta float64) string {
+	switch {
+	case delta > 0:
+		return "↑"
+	case delta < 0:
+		return "↓"
+	default:
+		return "="
+	}
+}
+
+func renderDiff(w io.Writer, diff runDiff) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Space", "Alloc", "AvgUse", "Ratio", "ΔRatio", "Waste", "ΔWaste"})
+
+	for _, delta := range diff.Apps {
+		app := delta.App
+		row := toValueList(&app)
+
+		if delta.Previous == nil {
+			row = append(row, "new", formatSize(app.Waste()), "new")
+		} else {
+			ratioDelta := app.Ratio - delta.Previous.Ratio
+			wasteDelta := app.Waste() - delta.Previous.Waste()
+			row = append(row,
+				fmt.Sprintf("%s %+.2f", trendArrow(ratioDelta), ratioDelta),
+				formatSize(app.Waste()),
+				fmt.Sprintf("%s %s", trendArrow(float64(wasteDelta)), formatSignedSize(wasteDelta)))
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\nNew offenders (%d):\n", len(diff.NewOffenders))
+	for _, app := range diff.NewOffenders {
+		fmt.Fprintf(w, "  %s (%s) ratio %.2f\n", app.Name, app.Space, app.Ratio)
+	}
+
+	fmt.Fprintf(w, "\nFixed (%d):\n", len(diff.Fixed))
+	for _, app := range diff.Fixed {
+		fmt.Fprintf(w, "  %s (%s)\n", app.Name, app.Space)
+	}
+}
+
+func formatSignedSize(size int) string {
+	if size < 0 {
+		return "-" + formatSize(-size)
+	}
+	return "+" + formatSize(size)
+}
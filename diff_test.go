@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestDiffRunsKeepsProcessesApart(t *testing.T) {
+
+	previous := []appStatSummary{
+		{GUID: "app-1", Name: "api", ProcessType: "web", Ratio: 4},
+		{GUID: "app-1", Name: "api", ProcessType: "worker", Ratio: 1},
+	}
+	current := []appStatSummary{
+		{GUID: "app-1", Name: "api", ProcessType: "web", Ratio: 1.5},
+		{GUID: "app-1", Name: "api", ProcessType: "worker", Ratio: 3},
+	}
+
+	diff := diffRuns(previous, current, 2)
+
+	if len(diff.NewOffenders) != 1 || diff.NewOffenders[0].ProcessType != "worker" {
+		t.Errorf("expected worker as the only new offender, got %+v", diff.NewOffenders)
+	}
+	if len(diff.Fixed) != 1 || diff.Fixed[0].ProcessType != "web" {
+		t.Errorf("expected web as the only fixed row, got %+v", diff.Fixed)
+	}
+	for _, delta := range diff.Apps {
+		if delta.Previous == nil || delta.Previous.ProcessType != delta.App.ProcessType {
+			t.Errorf("%s was diffed against the wrong row: %+v", delta.App.ProcessType, delta.Previous)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+type duplicateApp struct {
+	Name      string
+	Locations []string
+	Memory    int
+	guids     map[string]bool
+}
+
+// findDuplicates groups apps by name. With --processes an app spans several
+// rows, so copies are counted per GUID while memory adds up every row.
+func findDuplicates(appStats []appStatSummary) []*duplicateApp {
+
+	byName := map[string]*duplicateApp{}
+	for _, app := range appStats {
+		duplicate, ok := byName[app.Name]
+		if !ok {
+			duplicate = &duplicateApp{Name: app.Name, guids: map[string]bool{}}
+			byName[app.Name] = duplicate
+		}
+		if !duplicate.guids[app.GUID] {
+			duplicate.guids[app.GUID] = true
+			duplicate.Locations = append(duplicate.Locations, app.Org+"/"+app.SpaceName)
+		}
+		duplicate.Memory += app.MemoryAlloc * app.Instances
+	}
+
+	var duplicates []*duplicateApp
+	for _, duplicate := range byName {
+		if len(duplicate.Locations) > 1 {
+			sort.Strings(duplicate.Locations)
+			duplicates = append(duplicates, duplicate)
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Memory > duplicates[j].Memory })
+	return duplicates
+}
+
+func (hallOfShame *HallOfShame) runDuplicatesCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	appStats, err := hallOfShame.collect(cliConnection, opts)
+	if err != nil {
+		return err
+	}
+
+	renderDuplicates(os.Stdout, findDuplicates(applyFilters(opts, appStats)))
+	return nil
+}
+
+func renderDuplicates(w io.Writer, duplicates []*duplicateApp) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Copies", "Locations", "Combined Memory"})
+
+	var total int
+	for _, duplicate := range duplicates {
+		table.Append([]string{
+			duplicate.Name,
+			fmt.Sprintf("%d", len(duplicate.Locations)),
+			strings.Join(duplicate.Locations, ", "),
+			formatSize(duplicate.Memory),
+		})
+		total += duplicate.Memory
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d app names are deployed to more than one space, holding %s between them.\n", len(duplicates), formatSize(total))
+}
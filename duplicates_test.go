@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestFindDuplicatesCountsAppsNotProcesses(t *testing.T) {
+
+	appStats := []appStatSummary{
+		{GUID: "app-1", Name: "api", Org: "acme", SpaceName: "dev", ProcessType: "web", Instances: 2, MemoryAlloc: 1 << 30},
+		{GUID: "app-1", Name: "api", Org: "acme", SpaceName: "dev", ProcessType: "worker", Instances: 1, MemoryAlloc: 1 << 30},
+		{GUID: "app-2", Name: "billing", Org: "acme", SpaceName: "dev", ProcessType: "web", Instances: 1, MemoryAlloc: 1 << 30},
+		{GUID: "app-2", Name: "billing", Org: "acme", SpaceName: "dev", ProcessType: "worker", Instances: 1, MemoryAlloc: 1 << 30},
+		{GUID: "app-3", Name: "billing", Org: "acme", SpaceName: "prod", ProcessType: "web", Instances: 1, MemoryAlloc: 1 << 30},
+	}
+
+	duplicates := findDuplicates(appStats)
+
+	if len(duplicates) != 1 || duplicates[0].Name != "billing" {
+		t.Fatalf("expected only billing to be duplicated, got %+v", duplicates)
+	}
+	if len(duplicates[0].Locations) != 2 {
+		t.Errorf("expected two copies of billing, got %v", duplicates[0].Locations)
+	}
+	if duplicates[0].Memory != 3<<30 {
+		t.Errorf("expected every billing process to count towards memory, got %s", formatSize(duplicates[0].Memory))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+type V3AppEnv struct {
+	EnvironmentVariables json.RawMessage `json:"environment_variables"`
+	SystemEnvJSON        json.RawMessage `json:"system_env_json"`
+}
+
+type envSummary struct {
+	Name      string
+	SpaceName string
+	Org       string
+	UserBytes int
+	Total     int
+}
+
+func (hallOfShame *HallOfShame) GetAppEnvSize(cliConnection plugin.CliConnection, appGuid string) (int, int, error) {
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", fmt.Sprintf("/v3/apps/%v/env", appGuid))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	body := strings.Join(output, "")
+
+	res := V3AppEnv{}
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		return 0, 0, err
+	}
+
+	return len(res.EnvironmentVariables), len(res.SystemEnvJSON) + len(res.EnvironmentVariables), nil
+}
+
+func (hallOfShame *HallOfShame) runEnvCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	var apps []*envSummary
+	var mutex sync.Mutex
+
+	bar := newProgressBar(len(res.Resources))
+	bar.Start()
+
+	wg := sizedwaitgroup.New(2)
+	for _, app := range res.Resources {
+		wg.Add()
+
+		go func(cfApp *AppSearchResoures) {
+			defer wg.Done()
+
+			user, total, err := hallOfShame.GetAppEnvSize(cliConnection, cfApp.Metadata.Guid)
+			bar.Increment()
+			if err != nil || total < opts.envThreshold {
+				return
+			}
+
+			spaceNames := names.resolve(cfApp.Entity.SpaceGuid)
+			summary := &envSummary{Name: cfApp.Entity.Name, SpaceName: spaceNames.space, Org: spaceNames.org, UserBytes: user, Total: total}
+
+			mutex.Lock()
+			apps = append(apps, summary)
+			mutex.Unlock()
+		}(app)
+	}
+
+	wg.Wait()
+	bar.FinishPrint("Done!")
+
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Total > apps[j].Total })
+
+	renderEnv(os.Stdout, apps, opts.envThreshold)
+	return nil
+}
+
+// renderEnv lists the apps already found to be over the threshold.
+func renderEnv(w io.Writer, apps []*envSummary, threshold int) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "User Env", "Incl. VCAP_SERVICES"})
+
+	for _, app := range apps {
+		table.Append([]string{app.Name, app.Org, app.SpaceName, formatSize(app.UserBytes), formatSize(app.Total)})
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d apps carry at least %s of environment JSON.\n", len(apps), formatSize(threshold))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+type etagEntry struct {
+	etag string
+	body string
+}
+
+// etagCache makes repeated daemon collections conditional: a response that
+// carried an ETag is replayed from memory when CAPI answers the next
+// If-None-Match with 304 Not Modified. Endpoints without ETags behave as a
+// plain cf curl.
+type etagCache struct {
+	mutex     sync.Mutex
+	enabled   bool
+	entries   map[string]etagEntry
+	requests  int
+	unchanged int
+}
+
+var conditionalRequests = &etagCache{entries: map[string]etagEntry{}}
+
+func (cache *etagCache) curl(cliConnection plugin.CliConnection, path string) ([]string, error) {
+
+	cache.mutex.Lock()
+	enabled := cache.enabled
+	entry, cached := cache.entries[path]
+	cache.mutex.Unlock()
+
+	if !enabled {
+		return cliConnection.CliCommandWithoutTerminalOutput("curl", path)
+	}
+
+	args := []string{"curl", "-i", path}
+	if cached {
+		args = append(args, "-H", "If-None-Match: "+entry.etag)
+	}
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput(args...)
+	if err != nil {
+		return output, err
+	}
+
+	status, header, body := splitCurlResponse(output)
+	etag := header.Get("ETag")
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.requests++
+	if status == "304" && cached {
+		cache.unchanged++
+		return []string{entry.body}, nil
+	}
+	if etag != "" && strings.HasPrefix(status, "2") {
+		cache.entries[path] = etagEntry{etag: etag, body: body}
+	} else {
+		delete(cache.entries, path)
+	}
+	return []string{body}, nil
+}
+
+// stats returns and resets the request counters for the last collection.
+func (cache *etagCache) stats() (requests int, unchanged int) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	requests, unchanged = cache.requests, cache.unchanged
+	cache.requests, cache.unchanged = 0, 0
+	return requests, unchanged
+}
+
+// splitCurlResponse separates `cf curl -i` output into the status code, the
+// response headers and the body.
+func splitCurlResponse(output []string) (status string, header http.Header, body string) {
+
+	header = http.Header{}
+	lines := strings.Split(strings.Join(output, "\n"), "\n")
+
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if line == "" {
+			break
+		}
+		if i == 0 && strings.HasPrefix(line, "HTTP/") {
+			if fields := strings.Fields(line); len(fields) > 1 {
+				status = fields[1]
+			}
+			continue
+		}
+		if colon := strings.Index(line, ":"); colon > 0 {
+			header.Add(strings.TrimSpace(line[:colon]), strings.TrimSpace(line[colon+1:]))
+		}
+	}
+
+	if status == "" {
+		return "", header, strings.Join(output, "\n")
+	}
+	if i < len(lines) {
+		body = strings.Join(lines[i+1:], "\n")
+	}
+	return status, header, body
+}
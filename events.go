@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// recentCrashWindow bounds how old a crash can be and still be reported.
+const recentCrashWindow = 7 * 24 * time.Hour
+
+var pushEventTypes = []string{"audit.app.update", "audit.app.droplet.mapped", "audit.app.upload"}
+
+type AuditEventResults struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []struct {
+		Type      string `json:"type"`
+		CreatedAt string `json:"created_at"`
+		Actor     struct {
+			Guid string `json:"guid"`
+			Type string `json:"type"`
+			Name string `json:"name"`
+		} `json:"actor"`
+		Target struct {
+			Guid string `json:"guid"`
+		} `json:"target"`
+		Data struct {
+			Index           int    `json:"index"`
+			Reason          string `json:"reason"`
+			ExitDescription string `json:"exit_description"`
+		} `json:"data"`
+	} `json:"resources"`
+}
+
+func (hallOfShame *HallOfShame) GetLastPusher(cliConnection plugin.CliConnection, appGuid string) (string, error) {
+
+	query := fmt.Sprintf("/v3/audit_events?target_guids=%v&types=%v&order_by=-created_at&per_page=1",
+		appGuid, strings.Join(pushEventTypes, ","))
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+	if err != nil {
+		return "", err
+	}
+
+	res := AuditEventResults{}
+	if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+		return "", err
+	}
+
+	if len(res.Resources) == 0 {
+		return "", nil
+	}
+	return res.Resources[0].Actor.Name, nil
+}
+
+func (hallOfShame *HallOfShame) resolvePushers(cliConnection plugin.CliConnection, appStats []appStatSummary) {
+
+	wg := sizedwaitgroup.New(2)
+	for i := range appStats {
+		wg.Add()
+
+		go func(app *appStatSummary) {
+			defer wg.Done()
+
+			if pusher, err := hallOfShame.GetLastPusher(cliConnection, app.GUID); err == nil {
+				app.PushedBy = pusher
+			}
+		}(&appStats[i])
+	}
+
+	wg.Wait()
+}
+
+type crashEvent struct {
+	At              time.Time
+	Index           int
+	Reason          string
+	ExitDescription string
+}
+
+func (event *crashEvent) String() string {
+	if event.ExitDescription == "" {
+		return event.Reason
+	}
+	return fmt.Sprintf("%s: %s", event.Reason, event.ExitDescription)
+}
+
+// GetLastCrash returns the app's most recent crash within recentCrashWindow,
+// or nil when it hasn't crashed.
+func (hallOfShame *HallOfShame) GetLastCrash(cliConnection plugin.CliConnection, appGuid string) (*crashEvent, error) {
+
+	since := time.Now().Add(-recentCrashWindow).UTC().Format(time.RFC3339)
+	query := fmt.Sprintf("/v3/audit_events?target_guids=%v&types=audit.app.process.crash&created_ats%%5Bgt%%5D=%v&order_by=-created_at&per_page=1",
+		appGuid, url.QueryEscape(since))
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := AuditEventResults{}
+	if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+		return nil, err
+	}
+
+	if len(res.Resources) == 0 {
+		return nil, nil
+	}
+	event := res.Resources[0]
+	at, _ := time.Parse(time.RFC3339, event.CreatedAt)
+	return &crashEvent{At: at, Index: event.Data.Index, Reason: event.Data.Reason, ExitDescription: event.Data.ExitDescription}, nil
+}
+
+func (hallOfShame *HallOfShame) resolveCrashes(cliConnection plugin.CliConnection, appStats []appStatSummary) {
+
+	wg := sizedwaitgroup.New(2)
+	for i := range appStats {
+		wg.Add()
+
+		go func(app *appStatSummary) {
+			defer wg.Done()
+
+			if crash, err := hallOfShame.GetLastCrash(cliConnection, app.GUID); err == nil && crash != nil {
+				app.LastCrash = crash.String()
+			}
+		}(&appStats[i])
+	}
+
+	wg.Wait()
+}
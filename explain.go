@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+const explainSampleInterval = 5 * time.Second
+
+var lifecycleEventTypes = []string{"audit.app.process.crash", "audit.app.process.rescheduling", "audit.app.restart", "audit.app.start", "audit.app.stop", "audit.app.restage"}
+
+type instanceSamples struct {
+	index  string
+	latest AppStat
+	mem    []int
+}
+
+func (hallOfShame *HallOfShame) FindAppsByName(cliConnection plugin.CliConnection, name string) ([]*AppSearchResoures, error) {
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", "/v2/apps?q="+url.QueryEscape("name:"+name))
+	if err != nil {
+		return nil, err
+	}
+
+	res := AppSearchResults{}
+	if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+		return nil, err
+	}
+
+	return res.Resources, nil
+}
+
+func (hallOfShame *HallOfShame) GetLifecycleEvents(cliConnection plugin.CliConnection, appGuid string) (AuditEventResults, error) {
+
+	query := fmt.Sprintf("/v3/audit_events?target_guids=%v&types=%v&order_by=-created_at&per_page=10",
+		appGuid, strings.Join(lifecycleEventTypes, ","))
+
+	res := AuditEventResults{}
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+	if err != nil {
+		return res, err
+	}
+
+	err = json.Unmarshal([]byte(strings.Join(output, "")), &res)
+	return res, err
+}
+
+func (hallOfShame *HallOfShame) runExplainCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	if len(opts.args) != 1 {
+		return fmt.Errorf("usage: hall-of-shame explain <app-name> [--samples 3]")
+	}
+
+	apps, err := hallOfShame.FindAppsByName(cliConnection, opts.args[0])
+	if err != nil {
+		return err
+	}
+	if len(apps) == 0 {
+		return fmt.Errorf("no app named %s", opts.args[0])
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	for i, cfApp := range apps {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := hallOfShame.explainApp(os.Stdout, cliConnection, opts, names, cfApp); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	return nil
+}
+
+func (hallOfShame *HallOfShame) explainApp(w io.Writer, cliConnection plugin.CliConnection, opts *options, names *nameResolver, cfApp *AppSearchResoures) error {
+
+	spaceNames := names.resolve(cfApp.Entity.SpaceGuid)
+	fmt.Fprintf(w, "%s (%s/%s) %s\n", cfApp.Entity.Name, spaceNames.org, spaceNames.space, cfApp.Metadata.Guid)
+	fmt.Fprintf(w, "State %s, %d instances, %s memory each\n", cfApp.Entity.State, cfApp.Entity.Instances, formatSize(cfApp.Entity.Memory<<20))
+
+	if crash, err := hallOfShame.GetLastCrash(cliConnection, cfApp.Metadata.Guid); err == nil && crash != nil {
+		fmt.Fprintf(w, "Last crash %s ago, instance %d: %s\n", time.Since(crash.At).Round(time.Minute), crash.Index, crash)
+	}
+
+	if cfApp.Entity.State == "STOPPED" {
+		return nil
+	}
+
+	instances := map[string]*instanceSamples{}
+	for sample := 0; sample < opts.samples; sample++ {
+		if sample > 0 {
+			time.Sleep(explainSampleInterval)
+		}
+
+		stats, err := hallOfShame.GetAppStats(cliConnection, cfApp.Metadata.Guid)
+		if err != nil {
+			return err
+		}
+		for index, stat := range stats {
+			instance, ok := instances[index]
+			if !ok {
+				instance = &instanceSamples{index: index}
+				instances[index] = instance
+			}
+			instance.latest = stat
+			if stat.State == "RUNNING" {
+				instance.mem = append(instance.mem, stat.Stats.Usage.Mem)
+			}
+		}
+	}
+
+	var indexes []string
+	for index := range instances {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		if len(indexes[i]) != len(indexes[j]) {
+			return len(indexes[i]) < len(indexes[j])
+		}
+		return indexes[i] < indexes[j]
+	})
+
+	fmt.Fprintf(w, "\nPer-instance usage (%d samples, %s apart):\n", opts.samples, explainSampleInterval)
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Index", "State", "Host", "Uptime", "Mem Min", "Mem Avg", "Mem Max", "Quota", "Disk", "CPU"})
+
+	summary := appStatSummary{Name: cfApp.Entity.Name, Instances: cfApp.Entity.Instances}
+	var total, count int
+	for _, index := range indexes {
+		instance := instances[index]
+		stat := instance.latest
+
+		min, max, sum := 0, 0, 0
+		for i, mem := range instance.mem {
+			if i == 0 || mem < min {
+				min = mem
+			}
+			if mem > max {
+				max = mem
+			}
+			sum += mem
+		}
+		avg := 0
+		if len(instance.mem) > 0 {
+			avg = sum / len(instance.mem)
+			total += avg
+			count++
+		}
+		if stat.Stats.MemQuota > 0 {
+			summary.MemoryAlloc = stat.Stats.MemQuota
+		}
+
+		table.Append([]string{
+			index,
+			stat.State,
+			stat.Stats.Host,
+			(time.Duration(stat.Stats.Uptime) * time.Second).String(),
+			formatSize(min),
+			formatSize(avg),
+			formatSize(max),
+			formatSize(stat.Stats.MemQuota),
+			fmt.Sprintf("%s / %s", formatSize(stat.Stats.Usage.Disk), formatSize(stat.Stats.DiskQuota)),
+			fmt.Sprintf("%.1f%%", stat.Stats.Usage.CPU*100),
+		})
+	}
+	table.Render()
+
+	if count > 0 {
+		summary.AvgMemoryUse = total / count
+	}
+	explainRecommendation(w, &summary)
+
+	if _, err := os.Stat(opts.historyDB); err == nil {
+		if store, err := openHistory(opts.historyDB); err == nil {
+			points, err := store.appHistoryByGUID(cfApp.Metadata.Guid)
+			store.Close()
+			if err == nil {
+				renderQuotaHistory(w, points)
+			}
+		}
+	}
+
+	events, err := hallOfShame.GetLifecycleEvents(cliConnection, cfApp.Metadata.Guid)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nRecent crash and restart events:")
+	if len(events.Resources) == 0 {
+		fmt.Fprintln(w, "  none")
+		return nil
+	}
+	eventTable := tablewriter.NewWriter(w)
+	eventTable.SetHeader([]string{"Time", "Type", "Actor", "Detail"})
+	for _, event := range events.Resources {
+		detail := event.Data.ExitDescription
+		if event.Type == "audit.app.process.crash" {
+			detail = fmt.Sprintf("instance %d: %s %s", event.Data.Index, event.Data.Reason, event.Data.ExitDescription)
+		}
+		eventTable.Append([]string{event.CreatedAt, strings.TrimPrefix(event.Type, "audit.app."), event.Actor.Name, detail})
+	}
+	eventTable.Render()
+
+	return nil
+}
+
+func explainRecommendation(w io.Writer, app *appStatSummary) {
+
+	fmt.Fprintln(w, "\nRecommendation:")
+	if app.AvgMemoryUse == 0 {
+		fmt.Fprintln(w, "  No running instance reported memory usage, so there is nothing to size against.")
+		return
+	}
+
+	recommended := app.RecommendedAlloc()
+	fmt.Fprintf(w, "  Instances average %s of their %s quota (ratio %.2f).\n",
+		formatSize(app.AvgMemoryUse), formatSize(app.MemoryAlloc), float64(app.MemoryAlloc)/float64(app.AvgMemoryUse))
+	fmt.Fprintf(w, "  Adding %.0f%% headroom and rounding up to the next %s gives %s per instance.\n",
+		(recommendedHeadroom-1)*100, formatSize(recommendedStep), formatSize(recommended))
+
+	if savings := app.Savings(); savings > 0 {
+		fmt.Fprintf(w, "  Lowering the quota to %s would free %s across %d instances.\n", formatSize(recommended), formatSize(savings), app.Instances)
+	} else {
+		fmt.Fprintln(w, "  The current quota is already within that headroom; no change recommended.")
+	}
+}
+
+// renderQuotaHistory prints only the recorded runs where the quota or
+// instance count changed.
+func renderQuotaHistory(w io.Writer, points []historyPoint) {
+
+	if len(points) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nQuota history:")
+	var last *appStatSummary
+	for i := range points {
+		app := &points[i].App
+		if last != nil && last.MemoryAlloc == app.MemoryAlloc && last.Instances == app.Instances {
+			continue
+		}
+		fmt.Fprintf(w, "  %s  %d x %s\n", points[i].StartedAt.Format("2006-01-02"), app.Instances, formatSize(app.MemoryAlloc))
+		last = app
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+type fdsSummary struct {
+	Name      string
+	Space     string
+	Instances int
+	Quota     int
+	MaxUsed   int
+	Reported  bool
+}
+
+func (summary *fdsSummary) percent() float64 {
+	if summary.Quota == 0 {
+		return 0
+	}
+	return float64(summary.MaxUsed) / float64(summary.Quota) * 100
+}
+
+// runFdsCommand relies on usage.fds, which only newer Cloud Controllers
+// report; apps on older foundations are listed with their quota alone.
+func (hallOfShame *HallOfShame) runFdsCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	var apps []*fdsSummary
+
+	err := hallOfShame.forEachAppStats(cliConnection, func(cfApp *AppSearchResoures, stats map[string]AppStat) {
+		summary := &fdsSummary{Name: cfApp.Entity.Name, Space: cfApp.Entity.SpaceGuid}
+
+		for _, stat := range stats {
+			if stat.State != "RUNNING" {
+				continue
+			}
+			summary.Instances++
+			summary.Quota = stat.Stats.FdsQuota
+			if stat.Stats.Usage.Fds != nil {
+				summary.Reported = true
+				if *stat.Stats.Usage.Fds > summary.MaxUsed {
+					summary.MaxUsed = *stat.Stats.Usage.Fds
+				}
+			}
+		}
+
+		if summary.Instances > 0 {
+			apps = append(apps, summary)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(apps, func(i, j int) bool { return apps[i].percent() > apps[j].percent() })
+
+	renderFds(os.Stdout, apps, opts.fdsThreshold)
+	return nil
+}
+
+func renderFds(w io.Writer, apps []*fdsSummary, threshold float64) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Space", "Instances", "FD Quota", "Max FDs", "Used%", ""})
+
+	var flagged, unreported int
+	for _, app := range apps {
+		if !app.Reported {
+			unreported++
+			table.Append([]string{app.Name, app.Space, fmt.Sprintf("%d", app.Instances), fmt.Sprintf("%d", app.Quota), "n/a", "n/a", ""})
+			continue
+		}
+
+		mark := ""
+		if app.percent() >= threshold {
+			mark = "NEAR LIMIT"
+			flagged++
+		}
+		table.Append([]string{
+			app.Name,
+			app.Space,
+			fmt.Sprintf("%d", app.Instances),
+			fmt.Sprintf("%d", app.Quota),
+			fmt.Sprintf("%d", app.MaxUsed),
+			fmt.Sprintf("%.0f%%", app.percent()),
+			mark,
+		})
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d apps at or above %.0f%% of their file descriptor quota.\n", flagged, threshold)
+	if unreported > 0 {
+		fmt.Fprintf(w, "%d apps did not report descriptor usage (older Cloud Controller).\n", unreported)
+	}
+}
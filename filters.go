@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type appFilter func(app *appStatSummary) bool
+
+func buildFilters(opts *options) []appFilter {
+	var filters []appFilter
+
+	if len(opts.orgs) > 0 {
+		filters = append(filters, func(app *appStatSummary) bool {
+			return containsString(opts.orgs, app.Org)
+		})
+	}
+
+	if opts.isolationSegment != "" {
+		filters = append(filters, func(app *appStatSummary) bool {
+			segment := app.IsolationSegment
+			if segment == "" {
+				segment = sharedSegment
+			}
+			return segment == opts.isolationSegment
+		})
+	}
+
+	if opts.olderThan > 0 {
+		cutoff := time.Now().Add(-opts.olderThan)
+		filters = append(filters, func(app *appStatSummary) bool {
+			changed := app.LastChanged()
+			return !changed.IsZero() && changed.Before(cutoff)
+		})
+	}
+
+	if opts.minAlloc > 0 {
+		filters = append(filters, func(app *appStatSummary) bool {
+			return app.MemoryAlloc >= opts.minAlloc
+		})
+	}
+
+	if opts.minInstances > 0 {
+		filters = append(filters, func(app *appStatSummary) bool {
+			return app.Instances >= opts.minInstances
+		})
+	}
+
+	if opts.nameMatcher != nil {
+		filters = append(filters, func(app *appStatSummary) bool {
+			return opts.nameMatcher(app.Name)
+		})
+	}
+
+	if len(opts.states) > 0 {
+		filters = append(filters, func(app *appStatSummary) bool {
+			return containsString(opts.states, app.State)
+		})
+	}
+
+	if opts.stack != "" {
+		filters = append(filters, func(app *appStatSummary) bool {
+			return app.Stack == opts.stack
+		})
+	}
+
+	if opts.excludeAutoscaled {
+		filters = append(filters, func(app *appStatSummary) bool {
+			return !app.Autoscaled
+		})
+	}
+
+	return filters
+}
+
+func applyFilters(opts *options, appStats []appStatSummary) []appStatSummary {
+	filters := buildFilters(opts)
+	if len(filters) == 0 {
+		return appStats
+	}
+
+	var filtered []appStatSummary
+	for i := range appStats {
+		keep := true
+		for _, filter := range filters {
+			if !filter(&appStats[i]) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			filtered = append(filtered, appStats[i])
+		}
+	}
+
+	return filtered
+}
+
+func compileNameMatcher(pattern string) (func(string) bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid --name regex: %v", err)
+		}
+		return re.MatchString, nil
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid --name glob: %v", err)
+	}
+	return func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// foundationSkew is the ratio between the largest and smallest total
+// allocation of the same app above which it is flagged.
+const foundationSkew = 4.0
+
+type foundationSnapshot struct {
+	label string
+	apps  map[string]appStatSummary
+}
+
+// loadFoundations reads "label=path" arguments (or bare paths, labelled by
+// file name) pointing at --save snapshots taken against each foundation,
+// keying apps by org, space and app name (plus process type with
+// --processes), since space names repeat across orgs.
+func loadFoundations(args []string) ([]foundationSnapshot, error) {
+
+	var foundations []foundationSnapshot
+	for _, arg := range args {
+		label, path := "", arg
+		if parts := strings.SplitN(arg, "=", 2); len(parts) == 2 {
+			label, path = parts[0], parts[1]
+		} else {
+			label = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		snap, err := loadSnapshot(path)
+		if err != nil {
+			return nil, err
+		}
+
+		foundation := foundationSnapshot{label: label, apps: map[string]appStatSummary{}}
+		for _, app := range snap.Apps {
+			foundation.apps[app.Org+"/"+app.SpaceName+"/"+processAppName(app)] = app
+		}
+		foundations = append(foundations, foundation)
+	}
+
+	return foundations, nil
+}
+
+func runFoundationsCommand(opts *options) error {
+
+	if len(opts.args) < 2 {
+		return fmt.Errorf("usage: hall-of-shame compare-foundations prod=prod.json staging=staging.json [...]")
+	}
+
+	foundations, err := loadFoundations(opts.args)
+	if err != nil {
+		return err
+	}
+
+	renderFoundations(os.Stdout, foundations)
+	return nil
+}
+
+func renderFoundations(w io.Writer, foundations []foundationSnapshot) {
+
+	keys := map[string]bool{}
+	for _, foundation := range foundations {
+		for key := range foundation.apps {
+			keys[key] = true
+		}
+	}
+
+	var shared []string
+	for key := range keys {
+		present := 0
+		for _, foundation := range foundations {
+			if _, ok := foundation.apps[key]; ok {
+				present++
+			}
+		}
+		if present > 1 {
+			shared = append(shared, key)
+		}
+	}
+	sort.Strings(shared)
+
+	header := []string{"Org / Space / App"}
+	for _, foundation := range foundations {
+		header = append(header, foundation.label)
+	}
+	header = append(header, "")
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+
+	var skewed int
+	for _, key := range shared {
+		row := []string{key}
+		min, max := 0, 0
+		for _, foundation := range foundations {
+			app, ok := foundation.apps[key]
+			if !ok {
+				row = append(row, "-")
+				continue
+			}
+			total := app.MemoryAlloc * app.Instances
+			row = append(row, fmt.Sprintf("%d x %s (%.1f)", app.Instances, formatSize(app.MemoryAlloc), app.Ratio))
+			if min == 0 || total < min {
+				min = total
+			}
+			if total > max {
+				max = total
+			}
+		}
+
+		mark := ""
+		if min > 0 && float64(max)/float64(min) >= foundationSkew {
+			mark = fmt.Sprintf("%.0fx apart", float64(max)/float64(min))
+			skewed++
+		}
+		table.Append(append(row, mark))
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d apps appear on more than one foundation; %d differ in total allocation by %.0fx or more.\n", len(shared), skewed, foundationSkew)
+}
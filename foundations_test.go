@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFoundationsKeepsOrgsApart(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "prod.json")
+	appStats := []appStatSummary{
+		{GUID: "app-1", Name: "api", Org: "acme", SpaceName: "prod", Instances: 1, MemoryAlloc: 1 << 30},
+		{GUID: "app-2", Name: "api", Org: "globex", SpaceName: "prod", Instances: 2, MemoryAlloc: 1 << 30},
+	}
+	if err := saveSnapshot(path, appStats); err != nil {
+		t.Fatal(err)
+	}
+
+	foundations, err := loadFoundations([]string{"prod=" + path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apps := foundations[0].apps
+	if len(apps) != 2 || apps["acme/prod/api"].GUID != "app-1" || apps["globex/prod/api"].GUID != "app-2" {
+		t.Errorf("expected one entry per org, got %v", apps)
+	}
+}
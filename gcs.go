@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+type gcsUploader struct {
+	bucket string
+	token  string
+}
+
+func newGCSUploader(opts *options) uploader {
+	return &gcsUploader{
+		bucket: opts.gcsBucket,
+		token:  os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+	}
+}
+
+func (uploader *gcsUploader) Upload(key string, body []byte, contentType string) error {
+
+	endpoint := fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		url.PathEscape(uploader.bucket), url.QueryEscape(key))
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+uploader.token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("gcs upload of %s failed: %s %s", key, resp.Status, msg)
+	}
+
+	return nil
+}
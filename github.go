@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const githubRollupMarker = "<!-- hall-of-shame:rollup -->"
+
+var githubMarkerPattern = regexp.MustCompile(`<!-- hall-of-shame:([^ ]+) -->`)
+
+type githubClient struct {
+	apiURL string
+	repo   string
+	token  string
+}
+
+type githubIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+func fileGitHubIssues(opts *options, appStats []appStatSummary) error {
+
+	client := &githubClient{
+		apiURL: strings.TrimSuffix(opts.githubAPIURL, "/"),
+		repo:   opts.githubRepo,
+		token:  opts.githubToken,
+	}
+
+	existing, err := client.openIssues()
+	if err != nil {
+		return err
+	}
+
+	var offenders []appStatSummary
+	for _, app := range appStats {
+		if app.Ratio > opts.offenderRatio {
+			offenders = append(offenders, app)
+		}
+	}
+
+	if opts.githubMode == "rollup" {
+		if len(offenders) == 0 {
+			return nil
+		}
+
+		var body strings.Builder
+		fmt.Fprintf(&body, "%s\n%d apps allocate more than %.1fx the memory they use.\n\n", githubRollupMarker, len(offenders), opts.offenderRatio)
+		body.WriteString("| App | Space | Instances | Alloc | Avg use | Ratio | Recommended | Savings |\n")
+		body.WriteString("|---|---|---|---|---|---|---|---|\n")
+
+		var totalSavings int
+		for _, app := range offenders {
+			fmt.Fprintf(&body, "| %s | %s | %d | %s | %s | %.2f | %s | %s |\n",
+				githubAppName(app), app.Org+"/"+app.SpaceName, app.Instances, formatSize(app.MemoryAlloc), formatSize(app.AvgMemoryUse),
+				app.Ratio, formatSize(app.RecommendedAlloc()), formatSize(app.Savings()))
+			totalSavings += app.Savings()
+		}
+		fmt.Fprintf(&body, "\nApplying every recommendation would reclaim %s.\n", formatSize(totalSavings))
+
+		title := fmt.Sprintf("Memory hall of shame: %d over-allocated apps", len(offenders))
+		return client.upsertIssue(existing["rollup"], title, body.String())
+	}
+
+	for _, app := range offenders {
+		title := fmt.Sprintf("Memory over-allocation: %s allocates %.1fx the memory it uses", processAppName(app), app.Ratio)
+		body := fmt.Sprintf("<!-- hall-of-shame:%s -->\n"+
+			"App **%s** (`%s`) in space `%s` is over-allocated.\n\n"+
+			"| Instances | Alloc | Avg use | Ratio |\n|---|---|---|---|\n| %d | %s | %s | %.2f |\n\n"+
+			"**Recommendation:** reduce the memory quota to %s per instance, saving %s.\n\n"+
+			"**Space owners:** %s\n%s",
+			app.Key(), app.Name, app.GUID, app.Org+"/"+app.SpaceName,
+			app.Instances, formatSize(app.MemoryAlloc), formatSize(app.AvgMemoryUse), app.Ratio,
+			formatSize(app.RecommendedAlloc()), formatSize(app.Savings()), ownerList(app.Owners), githubLink(app))
+
+		if err := client.upsertIssue(existing[app.Key()], title, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func githubAppName(app appStatSummary) string {
+	if app.AppsManagerURL == "" {
+		return app.Name
+	}
+	return fmt.Sprintf("[%s](%s)", app.Name, app.AppsManagerURL)
+}
+
+func githubLink(app appStatSummary) string {
+	if app.AppsManagerURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n[Open in Apps Manager](%s)\n", app.AppsManagerURL)
+}
+
+func (client *githubClient) openIssues() (map[string]int, error) {
+
+	issues := map[string]int{}
+
+	for page := 1; ; page++ {
+		var results []githubIssue
+		path := fmt.Sprintf("/repos/%s/issues?state=open&labels=hall-of-shame&per_page=100&page=%d", client.repo, page)
+		if err := client.do("GET", path, nil, &results); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range results {
+			if match := githubMarkerPattern.FindStringSubmatch(issue.Body); match != nil {
+				issues[match[1]] = issue.Number
+			}
+		}
+
+		if len(results) < 100 {
+			return issues, nil
+		}
+	}
+}
+
+func (client *githubClient) upsertIssue(number int, title string, body string) error {
+
+	if number != 0 {
+		update := map[string]interface{}{"title": title, "body": body}
+		return client.do("PATCH", fmt.Sprintf("/repos/%s/issues/%d", client.repo, number), update, nil)
+	}
+
+	create := map[string]interface{}{"title": title, "body": body, "labels": []string{"hall-of-shame"}}
+	return client.do("POST", fmt.Sprintf("/repos/%s/issues", client.repo), create, nil)
+}
+
+func (client *githubClient) do(method string, path string, body interface{}, result interface{}) error {
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, client.apiURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+client.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("github %s %s failed: %s %s", method, path, resp.Status, msg)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type grafanaAnnotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+func postGrafanaAnnotation(opts *options, appStats []appStatSummary) error {
+
+	var reclaimable, apps int
+	for _, app := range appStats {
+		if savings := app.Savings(); savings > 0 {
+			reclaimable += savings
+			apps++
+		}
+	}
+
+	return sendGrafanaAnnotation(opts, fmt.Sprintf("hall-of-shame: %s reclaimable across %d apps", formatSize(reclaimable), apps))
+}
+
+func postBudgetAnnotations(opts *options, appStats []appStatSummary) error {
+
+	statuses, err := checkBudgets(opts, appStats)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if !status.exceeded() {
+			continue
+		}
+		text := fmt.Sprintf("hall-of-shame: org %s is over budget (%s allocated)", status.Org, formatSize(status.Allocated))
+		if err := sendGrafanaAnnotation(opts, text, "budget"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sendGrafanaAnnotation(opts *options, text string, extraTags ...string) error {
+
+	var tags []string
+	for _, tag := range strings.Split(opts.grafanaTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	annotation := grafanaAnnotation{
+		DashboardUID: opts.grafanaDashboardUID,
+		Time:         time.Now().UnixNano() / int64(time.Millisecond),
+		Tags:         append(tags, extraTags...),
+		Text:         text,
+	}
+
+	body, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(opts.grafanaURL, "/")+"/api/annotations", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+opts.grafanaToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("grafana annotation failed: %s %s", resp.Status, msg)
+	}
+
+	return nil
+}
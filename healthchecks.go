@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+// defaultHealthCheckTimeout is what Diego uses when an app sets none.
+const defaultHealthCheckTimeout = 60 * time.Second
+
+type healthCheckFinding struct {
+	Name      string
+	Org       string
+	SpaceName string
+	Type      string
+	Timeout   time.Duration
+	Endpoint  string
+	Problems  []string
+}
+
+func healthCheckTimeout(entity *AppSearchEntity) time.Duration {
+	if entity.HealthCheckTimeout != nil && *entity.HealthCheckTimeout > 0 {
+		return time.Duration(*entity.HealthCheckTimeout) * time.Second
+	}
+	return defaultHealthCheckTimeout
+}
+
+// auditHealthCheck returns what is wrong with an app's health check: a
+// timeout above maxTimeout, an HTTP check without a usable endpoint, or no
+// check at all.
+func auditHealthCheck(entity *AppSearchEntity, maxTimeout time.Duration) []string {
+
+	var problems []string
+
+	if timeout := healthCheckTimeout(entity); timeout > maxTimeout {
+		problems = append(problems, fmt.Sprintf("timeout %s above %s", timeout, maxTimeout))
+	}
+
+	switch entity.HealthCheckType {
+	case "http":
+		endpoint := entity.HealthCheckHTTPEndpoint
+		switch {
+		case endpoint == "":
+			problems = append(problems, "http check without an endpoint")
+		case !strings.HasPrefix(endpoint, "/") || strings.ContainsAny(endpoint, " \t"):
+			problems = append(problems, fmt.Sprintf("invalid endpoint %q", endpoint))
+		}
+	case "none":
+		problems = append(problems, "no health check")
+	}
+
+	return problems
+}
+
+func (hallOfShame *HallOfShame) runHealthChecksCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	var findings []*healthCheckFinding
+	for _, app := range res.Resources {
+		problems := auditHealthCheck(app.Entity, opts.maxHealthTimeout)
+		if len(problems) == 0 {
+			continue
+		}
+
+		finding := &healthCheckFinding{
+			Name:     app.Entity.Name,
+			Type:     app.Entity.HealthCheckType,
+			Timeout:  healthCheckTimeout(app.Entity),
+			Endpoint: app.Entity.HealthCheckHTTPEndpoint,
+			Problems: problems,
+		}
+		spaceNames := names.resolve(app.Entity.SpaceGuid)
+		finding.SpaceName, finding.Org = spaceNames.space, spaceNames.org
+
+		findings = append(findings, finding)
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Timeout != findings[j].Timeout {
+			return findings[i].Timeout > findings[j].Timeout
+		}
+		return findings[i].Name < findings[j].Name
+	})
+
+	renderHealthChecks(os.Stdout, findings, len(res.Resources))
+	return nil
+}
+
+func renderHealthChecks(w io.Writer, findings []*healthCheckFinding, total int) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Type", "Timeout", "Endpoint", "Problem"})
+
+	for _, finding := range findings {
+		table.Append([]string{
+			finding.Name,
+			finding.Org,
+			finding.SpaceName,
+			finding.Type,
+			finding.Timeout.String(),
+			finding.Endpoint,
+			strings.Join(finding.Problems, "; "),
+		})
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d of %d apps have a health check problem.\n", len(findings), total)
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/olekukonko/tablewriter"
+)
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS app_stats (
+	run_id         INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+	guid           TEXT NOT NULL,
+	name           TEXT NOT NULL,
+	space          TEXT NOT NULL,
+	instances      INTEGER NOT NULL,
+	memory_alloc   INTEGER NOT NULL,
+	avg_memory_use INTEGER NOT NULL,
+	ratio          REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS app_stats_run ON app_stats (run_id);
+CREATE INDEX IF NOT EXISTS app_stats_guid ON app_stats (guid);
+CREATE INDEX IF NOT EXISTS app_stats_name ON app_stats (name);
+`
+
+var historyMigrations = []string{
+	"ALTER TABLE app_stats ADD COLUMN space_name TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE app_stats ADD COLUMN org TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE app_stats ADD COLUMN process_type TEXT NOT NULL DEFAULT ''",
+}
+
+type historyStore struct {
+	db *sql.DB
+}
+
+func openHistory(path string) (*historyStore, error) {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	for _, migration := range historyMigrations {
+		if _, err := db.Exec(migration); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return &historyStore{db: db}, nil
+}
+
+func (store *historyStore) Close() error {
+	return store.db.Close()
+}
+
+func (store *historyStore) recordRun(startedAt time.Time, appStats []appStatSummary) error {
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO runs (started_at) VALUES (?)", startedAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	insert, err := tx.Prepare(`INSERT INTO app_stats
+		(run_id, guid, name, space, space_name, org, process_type, instances, memory_alloc, avg_memory_use, ratio)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, app := range appStats {
+		if _, err := insert.Exec(runID, app.GUID, app.Name, app.Space, app.SpaceName, app.Org, app.ProcessType, app.Instances, app.MemoryAlloc, app.AvgMemoryUse, app.Ratio); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (store *historyStore) lastRun() ([]appStatSummary, error) {
+
+	rows, err := store.db.Query(`SELECT guid, name, space, space_name, org, process_type, instances, memory_alloc, avg_memory_use, ratio
+		FROM app_stats WHERE run_id = (SELECT MAX(id) FROM runs)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appStats []appStatSummary
+	for rows.Next() {
+		app := appStatSummary{}
+		if err := rows.Scan(&app.GUID, &app.Name, &app.Space, &app.SpaceName, &app.Org, &app.ProcessType, &app.Instances, &app.MemoryAlloc, &app.AvgMemoryUse, &app.Ratio); err != nil {
+			return nil, err
+		}
+		appStats = append(appStats, app)
+	}
+
+	return appStats, rows.Err()
+}
+
+type historyPoint struct {
+	StartedAt time.Time      `json:"started_at"`
+	App       appStatSummary `json:"app"`
+}
+
+type historyRun struct {
+	ID         int64     `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	Apps       int       `json:"apps"`
+	TotalAlloc int       `json:"total_alloc"`
+	TotalWaste int       `json:"total_waste"`
+}
+
+func (store *historyStore) runs() ([]historyRun, error) {
+
+	rows, err := store.db.Query(`SELECT runs.id, runs.started_at, COUNT(app_stats.guid),
+		COALESCE(SUM(app_stats.memory_alloc * app_stats.instances), 0),
+		COALESCE(SUM(MAX(app_stats.memory_alloc - app_stats.avg_memory_use, 0) * app_stats.instances), 0)
+		FROM runs LEFT JOIN app_stats ON app_stats.run_id = runs.id
+		GROUP BY runs.id ORDER BY runs.started_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []historyRun
+	for rows.Next() {
+		run := historyRun{}
+		if err := rows.Scan(&run.ID, &run.StartedAt, &run.Apps, &run.TotalAlloc, &run.TotalWaste); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (store *historyStore) appHistory(name string) ([]historyPoint, error) {
+	return store.queryHistory("name = ?", name)
+}
+
+func (store *historyStore) appHistoryByGUID(guid string) ([]historyPoint, error) {
+	return store.queryHistory("guid = ?", guid)
+}
+
+func (store *historyStore) queryHistory(where string, args ...interface{}) ([]historyPoint, error) {
+
+	rows, err := store.db.Query(`SELECT runs.started_at, guid, name, space, space_name, org, process_type, instances, memory_alloc, avg_memory_use, ratio
+		FROM app_stats JOIN runs ON runs.id = app_stats.run_id
+		WHERE `+where+` ORDER BY runs.started_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []historyPoint
+	for rows.Next() {
+		point := historyPoint{}
+		app := &point.App
+		if err := rows.Scan(&point.StartedAt, &app.GUID, &app.Name, &app.Space, &app.SpaceName, &app.Org, &app.ProcessType, &app.Instances, &app.MemoryAlloc, &app.AvgMemoryUse, &app.Ratio); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
+
+func (store *historyStore) prune(before time.Time) (int64, error) {
+
+	res, err := store.db.Exec("DELETE FROM runs WHERE started_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	pruned, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if pruned > 0 {
+		if _, err := store.db.Exec("VACUUM"); err != nil {
+			return pruned, err
+		}
+	}
+
+	return pruned, nil
+}
+
+func recordHistory(opts *options, appStats []appStatSummary) error {
+
+	store, err := openHistory(opts.historyDB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.recordRun(time.Now(), appStats); err != nil {
+		return err
+	}
+
+	if opts.historyRetain > 0 {
+		_, err = store.prune(time.Now().Add(-opts.historyRetain))
+	}
+
+	return err
+}
+
+func runHistoryCommand(opts *options) error {
+
+	if len(opts.args) != 1 {
+		return fmt.Errorf("usage: hall-of-shame history <app-name> | prune --history-retain 90d")
+	}
+
+	store, err := openHistory(opts.historyDB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch opts.args[0] {
+	case "prune":
+		if opts.historyRetain <= 0 {
+			return fmt.Errorf("history prune requires --history-retain")
+		}
+		pruned, err := store.prune(time.Now().Add(-opts.historyRetain))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Pruned %d runs older than %s.\n", pruned, opts.historyRetain)
+		return nil
+	}
+
+	points, err := store.appHistory(opts.args[0])
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("no history recorded for %s", opts.args[0])
+	}
+
+	renderAppHistory(os.Stdout, points)
+	return nil
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+func sparkline(values []float64) string {
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+
+	line := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if max > min {
+			level = int((v - min) / (max - min) * float64(len(sparkBlocks)-1))
+		}
+		line[i] = sparkBlocks[level]
+	}
+	return string(line)
+}
+
+// renderAppHistory draws one table and sparkline per series. A name can match
+// apps in several spaces, and --processes records a row per process, so the
+// points are split by GUID and process type first.
+func renderAppHistory(w io.Writer, points []historyPoint) {
+
+	var keys []string
+	series := map[string][]historyPoint{}
+	for _, point := range points {
+		key := point.App.Key()
+		if _, ok := series[key]; !ok {
+			keys = append(keys, key)
+		}
+		series[key] = append(series[key], point)
+	}
+
+	for i, key := range keys {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if len(keys) > 1 {
+			app := series[key][0].App
+			fmt.Fprintf(w, "%s in %s\n", processAppName(app), historySpace(app))
+		}
+		renderHistorySeries(w, series[key])
+	}
+}
+
+func renderHistorySeries(w io.Writer, points []historyPoint) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Run", "Space", "Instances", "Alloc", "AvgUse", "Ratio"})
+
+	var ratios, usage []float64
+	for _, point := range points {
+		app := point.App
+		table.Append([]string{
+			point.StartedAt.Local().Format("2006-01-02 15:04"),
+			historySpace(app),
+			fmt.Sprintf("%d", app.Instances),
+			formatSize(app.MemoryAlloc),
+			formatSize(app.AvgMemoryUse),
+			fmt.Sprintf("%.2f", app.Ratio),
+		})
+		ratios = append(ratios, app.Ratio)
+		usage = append(usage, float64(app.AvgMemoryUse))
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\nRatio  %s\n", sparkline(ratios))
+	fmt.Fprintf(w, "AvgUse %s\n", sparkline(usage))
+}
+
+// historySpace names the space as org/space. Runs recorded before names were
+// stored only have the space GUID.
+func historySpace(app appStatSummary) string {
+	if app.SpaceName == "" {
+		return app.Space
+	}
+	return app.Org + "/" + app.SpaceName
+}
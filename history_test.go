@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderAppHistorySplitsSeries(t *testing.T) {
+
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	appStats := []appStatSummary{
+		{GUID: "app-shop", Name: "shop", Space: "space-prod", SpaceName: "prod", Org: "acme", ProcessType: "web", Instances: 2, MemoryAlloc: 1 << 30, AvgMemoryUse: 256 << 20, Ratio: 4},
+		{GUID: "app-shop", Name: "shop", Space: "space-prod", SpaceName: "prod", Org: "acme", ProcessType: "worker", Instances: 1, MemoryAlloc: 512 << 20, AvgMemoryUse: 256 << 20, Ratio: 2},
+	}
+	for _, at := range []time.Time{time.Now().Add(-time.Hour), time.Now()} {
+		if err := store.recordRun(at, appStats); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	points, err := store.appHistory("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := &bytes.Buffer{}
+	renderAppHistory(buffer, points)
+	output := buffer.String()
+
+	for _, heading := range []string{"shop (web) in acme/prod", "shop (worker) in acme/prod"} {
+		if !strings.Contains(output, heading) {
+			t.Errorf("missing series %q in:\n%s", heading, output)
+		}
+	}
+	if strings.Count(output, "Ratio  ") != 2 {
+		t.Errorf("expected one sparkline per process:\n%s", output)
+	}
+	if strings.Contains(output, "space-prod") {
+		t.Errorf("expected the space name rather than its GUID:\n%s", output)
+	}
+}
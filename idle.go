@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+func (hallOfShame *HallOfShame) runIdleCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	appStats, err := hallOfShame.collect(cliConnection, opts)
+	if err != nil {
+		return err
+	}
+	appStats = applyFilters(opts, appStats)
+
+	client, err := newLogCacheClient(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-opts.idleFor)
+
+	var idle, unrouted []appStatSummary
+	for _, app := range appStats {
+		if app.State == "STOPPED" {
+			continue
+		}
+		// Workers and other apps without routes never see gorouter traffic,
+		// so the lack of requests says nothing about them.
+		if len(app.Routes) == 0 {
+			unrouted = append(unrouted, app)
+			continue
+		}
+
+		busy, err := client.hasRequests(app.GUID, since)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if !busy {
+			idle = append(idle, app)
+		}
+	}
+
+	sort.Slice(idle, func(i, j int) bool {
+		return idle[i].MemoryAlloc*idle[i].Instances > idle[j].MemoryAlloc*idle[j].Instances
+	})
+
+	if opts.output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(idle)
+	}
+
+	renderIdle(os.Stdout, idle, unrouted, opts.idleFor)
+	return nil
+}
+
+func renderIdle(w io.Writer, apps []appStatSummary, unrouted []appStatSummary, window time.Duration) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Instances", "Alloc", "Held"})
+
+	var held int
+	for _, app := range apps {
+		table.Append([]string{
+			app.Name,
+			app.Org,
+			app.SpaceName,
+			fmt.Sprintf("%d", app.Instances),
+			formatSize(app.MemoryAlloc),
+			formatSize(app.MemoryAlloc * app.Instances),
+		})
+		held += app.MemoryAlloc * app.Instances
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d apps received no HTTP requests in the last %s and hold %s of memory.\n", len(apps), window, formatSize(held))
+	fmt.Fprintln(w, "Log Cache retention is bounded, so very quiet foundations may report apps idle sooner than the window suggests.")
+
+	if len(unrouted) > 0 {
+		names := make([]string, len(unrouted))
+		for i, app := range unrouted {
+			names[i] = app.Name
+		}
+		fmt.Fprintf(w, "%d apps have no routes, so request traffic can't tell whether they are idle: %s\n", len(unrouted), strings.Join(names, ", "))
+	}
+}
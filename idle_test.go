@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderIdleListsUnroutedAppsSeparately(t *testing.T) {
+
+	idle := []appStatSummary{{Name: "dormant", Instances: 1, MemoryAlloc: 1 << 30, Routes: []string{"dormant.example.com"}}}
+	unrouted := []appStatSummary{{Name: "worker", Instances: 2, MemoryAlloc: 1 << 30}}
+
+	buffer := &bytes.Buffer{}
+	renderIdle(buffer, idle, unrouted, 24*time.Hour)
+	output := buffer.String()
+
+	if !strings.Contains(output, "1 apps received no HTTP requests") {
+		t.Errorf("only the routed app should be reported idle:\n%s", output)
+	}
+	if !strings.Contains(output, "1 apps have no routes, so request traffic can't tell whether they are idle: worker") {
+		t.Errorf("expected the unrouted app to be listed separately:\n%s", output)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+type appInventory struct {
+	Endpoint  string           `json:"endpoint"`
+	FetchedAt time.Time        `json:"fetched_at"`
+	Apps      AppSearchResults `json:"apps"`
+}
+
+// loadAppInventory returns the app list, reusing the one saved by an earlier
+// run when --cache is set and it is recent enough. Stats are always fetched
+// fresh; only the list of apps (and their instances, memory and state) is
+// reused.
+func (hallOfShame *HallOfShame) loadAppInventory(cliConnection plugin.CliConnection, opts *options) (AppSearchResults, error) {
+
+	if opts.cache <= 0 {
+		return hallOfShame.GetAllApps(cliConnection)
+	}
+
+	endpoint, _ := cliConnection.ApiEndpoint()
+	path := defaultStatePath("apps.json")
+
+	if data, err := ioutil.ReadFile(path); err == nil {
+		cached := appInventory{}
+		if err := json.Unmarshal(data, &cached); err == nil && cached.Endpoint == endpoint && time.Since(cached.FetchedAt) < opts.cache {
+			fmt.Fprintf(progressOutput, "Using app list cached %s ago\n", time.Since(cached.FetchedAt).Round(time.Second))
+			return cached.Apps, nil
+		}
+	}
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return res, err
+	}
+
+	if err := saveAppInventory(path, appInventory{Endpoint: endpoint, FetchedAt: time.Now().UTC(), Apps: res}); err != nil {
+		fmt.Println(err)
+	}
+	return res, nil
+}
+
+func saveAppInventory(path string, inventory appInventory) error {
+	data, err := json.Marshal(inventory)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Java buildpack memory calculator defaults, used for whatever the app's
+// JAVA_OPTS and JBP_CONFIG leave unset.
+const (
+	jbpDefaultThreads   = 250
+	jbpDefaultStack     = 1 << 20
+	jbpDefaultCodeCache = 240 << 20
+	jbpDefaultDirect    = 10 << 20
+)
+
+// javaHeadroomTight and javaHeadroomWasteful bound the share of the quota
+// left over after heap, metaspace and the other JVM regions.
+const (
+	javaHeadroomTight    = 0.05
+	javaHeadroomWasteful = 0.5
+)
+
+// javaEnvVars are where Java apps on Cloud Foundry set JVM options.
+var javaEnvVars = []string{"JAVA_OPTS", "JAVA_TOOL_OPTIONS", "JBP_CONFIG_JAVA_OPTS", "JBP_CONFIG_OPEN_JDK_JRE"}
+
+var (
+	javaOptionPattern   = regexp.MustCompile(`-(Xmx|Xss|XX:MaxMetaspaceSize=|XX:ReservedCodeCacheSize=|XX:MaxDirectMemorySize=)(\d+[kKmMgG]?)`)
+	stackThreadsPattern = regexp.MustCompile(`stack_threads\s*:\s*(\d+)`)
+)
+
+type javaMemoryConfig struct {
+	Name      string
+	Org       string
+	SpaceName string
+	Quota     int
+	Heap      int
+	Metaspace int
+	Threads   int
+	Stack     int
+	CodeCache int
+	Direct    int
+}
+
+// configured is the JVM's worst-case footprint: heap, metaspace, thread
+// stacks, code cache and direct memory. An unset metaspace counts as zero,
+// since the calculator sizes it from the droplet's class count, so headroom
+// is an upper bound for those apps.
+func (config *javaMemoryConfig) configured() int {
+	return config.Heap + config.Metaspace + config.Threads*config.Stack + config.CodeCache + config.Direct
+}
+
+func (config *javaMemoryConfig) headroom() float64 {
+	if config.Quota == 0 {
+		return 0
+	}
+	return float64(config.Quota-config.configured()) / float64(config.Quota)
+}
+
+func (config *javaMemoryConfig) verdict() string {
+	switch headroom := config.headroom(); {
+	case headroom < javaHeadroomTight:
+		return "TOO TIGHT"
+	case headroom > javaHeadroomWasteful:
+		return "WASTEFUL"
+	}
+	return ""
+}
+
+func isJavaApp(entity *AppSearchEntity) bool {
+	return strings.Contains(strings.ToLower(entity.Buildpack+" "+entity.DetectedBuildpack), "java")
+}
+
+// parseJavaMemoryConfig reads the explicit JVM sizes from the app's
+// environment. It returns false when no heap is set: the memory calculator
+// then sizes the heap to fit the quota, so there is nothing to judge.
+func parseJavaMemoryConfig(env map[string]string, quota int) (*javaMemoryConfig, bool) {
+
+	config := &javaMemoryConfig{
+		Quota:     quota,
+		Threads:   jbpDefaultThreads,
+		Stack:     jbpDefaultStack,
+		CodeCache: jbpDefaultCodeCache,
+		Direct:    jbpDefaultDirect,
+	}
+
+	for _, name := range javaEnvVars {
+		value := env[name]
+
+		for _, match := range javaOptionPattern.FindAllStringSubmatch(value, -1) {
+			size, err := parseSize(match[2])
+			if err != nil {
+				continue
+			}
+			switch match[1] {
+			case "Xmx":
+				config.Heap = size
+			case "Xss":
+				config.Stack = size
+			case "XX:MaxMetaspaceSize=":
+				config.Metaspace = size
+			case "XX:ReservedCodeCacheSize=":
+				config.CodeCache = size
+			case "XX:MaxDirectMemorySize=":
+				config.Direct = size
+			}
+		}
+
+		if match := stackThreadsPattern.FindStringSubmatch(value); match != nil {
+			config.Threads, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	return config, config.Heap > 0
+}
+
+func (hallOfShame *HallOfShame) GetAppEnvironment(cliConnection plugin.CliConnection, appGuid string) (map[string]string, error) {
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", fmt.Sprintf("/v3/apps/%v/env", appGuid))
+	if err != nil {
+		return nil, err
+	}
+
+	res := V3AppEnv{}
+	if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{}
+	if len(res.EnvironmentVariables) > 0 {
+		if err := json.Unmarshal(res.EnvironmentVariables, &env); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// runJavaCommand compares the JVM sizing of Java buildpack apps with their
+// container quota, flagging both ends: too little headroom invites OOM
+// kills, too much is quota the JVM will never touch.
+func (hallOfShame *HallOfShame) runJavaCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	var configs []*javaMemoryConfig
+	var automatic int
+	for _, cfApp := range res.Resources {
+		if !isJavaApp(cfApp.Entity) {
+			continue
+		}
+
+		env, err := hallOfShame.GetAppEnvironment(cliConnection, cfApp.Metadata.Guid)
+		if err != nil {
+			continue
+		}
+
+		config, explicit := parseJavaMemoryConfig(env, cfApp.Entity.Memory<<20)
+		if !explicit {
+			automatic++
+			continue
+		}
+		config.Name = cfApp.Entity.Name
+		spaceNames := names.resolve(cfApp.Entity.SpaceGuid)
+		config.SpaceName, config.Org = spaceNames.space, spaceNames.org
+		configs = append(configs, config)
+	}
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].headroom() < configs[j].headroom() })
+
+	renderJavaConfigs(os.Stdout, configs, automatic)
+	return nil
+}
+
+func renderJavaConfigs(w io.Writer, configs []*javaMemoryConfig, automatic int) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Quota", "Heap", "Metaspace", "Stacks", "Other", "Headroom", ""})
+
+	var tight, wasteful int
+	for _, config := range configs {
+		verdict := config.verdict()
+		switch verdict {
+		case "TOO TIGHT":
+			tight++
+		case "WASTEFUL":
+			wasteful++
+		}
+		table.Append([]string{
+			config.Name,
+			config.Org,
+			config.SpaceName,
+			formatSize(config.Quota),
+			formatSize(config.Heap),
+			formatSize(config.Metaspace),
+			fmt.Sprintf("%d x %s", config.Threads, formatSize(config.Stack)),
+			formatSize(config.CodeCache + config.Direct),
+			fmt.Sprintf("%.0f%%", config.headroom()*100),
+			verdict,
+		})
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d Java apps with explicit heap sizes: %d too tight (under %.0f%% headroom), %d wasteful (over %.0f%%).\n",
+		len(configs), tight, javaHeadroomTight*100, wasteful, javaHeadroomWasteful*100)
+	if automatic > 0 {
+		fmt.Fprintf(w, "%d Java apps leave heap sizing to the memory calculator and are not listed.\n", automatic)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type jiraClient struct {
+	baseURL string
+	user    string
+	token   string
+}
+
+type jiraSearchResults struct {
+	Issues []struct {
+		Key string `json:"key"`
+	} `json:"issues"`
+}
+
+func fileJiraIssues(opts *options, appStats []appStatSummary) error {
+
+	projects := map[string]string{}
+	if opts.jiraProjectsFile != "" {
+		data, err := ioutil.ReadFile(opts.jiraProjectsFile)
+		if err != nil {
+			return err
+		}
+		if err := validateConfig(opts.jiraProjectsFile, data, stringMapSchema); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &projects); err != nil {
+			return fmt.Errorf("reading %s: %v", opts.jiraProjectsFile, err)
+		}
+	}
+
+	client := &jiraClient{
+		baseURL: strings.TrimSuffix(opts.jiraURL, "/"),
+		user:    opts.jiraUser,
+		token:   opts.jiraToken,
+	}
+
+	for _, app := range appStats {
+		if app.Ratio <= opts.offenderRatio {
+			continue
+		}
+
+		project := jiraProjectFor(projects, app, opts.jiraProject)
+		if project == "" {
+			continue
+		}
+
+		if err := client.upsertIssue(project, opts.jiraIssueType, app); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jiraProjectFor files an app into its owning team's project. --jira-projects
+// keys are tried from most to least specific: the team assigned by
+// --team-map or --team-label, "org/space", then the org. Space GUIDs, the
+// original key, still match. Anything else goes to --jira-project.
+func jiraProjectFor(projects map[string]string, app appStatSummary, fallback string) string {
+	for _, key := range []string{app.Team, app.Org + "/" + app.SpaceName, app.Org, app.Space} {
+		if key == "" || key == "/" {
+			continue
+		}
+		if project, ok := projects[key]; ok {
+			return project
+		}
+	}
+	return fallback
+}
+
+func (client *jiraClient) upsertIssue(project string, issueType string, app appStatSummary) error {
+
+	label := "hall-of-shame-" + app.GUID
+	summary := fmt.Sprintf("Memory over-allocation: %s allocates %.1fx the memory it uses", app.Name, app.Ratio)
+	description := fmt.Sprintf(
+		"App *%s* (%s) in %s / %s is over-allocated.\n\n"+
+			"|| Instances || Allocated || Avg use || Ratio ||\n"+
+			"| %d | %s | %s | %.2f |\n\n"+
+			"*Recommendation:* reduce the memory quota to %s per instance.\n"+
+			"*Estimated savings:* %s across all instances.\n"+
+			"*Space owners:* %s\n"+
+			"%s\n"+
+			"_Filed by hall-of-shame; this issue is updated on each run._",
+		app.Name, app.GUID, app.Org, app.SpaceName,
+		app.Instances, formatSize(app.MemoryAlloc), formatSize(app.AvgMemoryUse), app.Ratio,
+		formatSize(app.RecommendedAlloc()), formatSize(app.Savings()), ownerList(app.Owners), jiraLink(app))
+
+	jql := fmt.Sprintf(`labels = "%s" AND statusCategory != Done`, label)
+
+	results := jiraSearchResults{}
+	if err := client.do("GET", "/rest/api/2/search?fields=key&jql="+url.QueryEscape(jql), nil, &results); err != nil {
+		return err
+	}
+
+	if len(results.Issues) > 0 {
+		update := map[string]interface{}{
+			"fields": map[string]interface{}{
+				"summary":     summary,
+				"description": description,
+			},
+		}
+		return client.do("PUT", "/rest/api/2/issue/"+results.Issues[0].Key, update, nil)
+	}
+
+	create := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": project},
+			"issuetype":   map[string]string{"name": issueType},
+			"summary":     summary,
+			"description": description,
+			"labels":      []string{"hall-of-shame", label},
+		},
+	}
+	return client.do("POST", "/rest/api/2/issue", create, nil)
+}
+
+func jiraLink(app appStatSummary) string {
+	if app.AppsManagerURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("[Open in Apps Manager|%s]\n", app.AppsManagerURL)
+}
+
+func (client *jiraClient) do(method string, path string, body interface{}, result interface{}) error {
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, client.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(client.user, client.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("jira %s %s failed: %s %s", method, path, resp.Status, msg)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
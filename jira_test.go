@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestJiraProjectFor(t *testing.T) {
+
+	projects := map[string]string{
+		"payments-team":  "PAY",
+		"acme/prod":      "PROD",
+		"acme":           "ACME",
+		"space-acme-dev": "LEGACY",
+	}
+
+	for _, test := range []struct {
+		app  appStatSummary
+		want string
+	}{
+		{appStatSummary{Team: "payments-team", Org: "acme", SpaceName: "prod"}, "PAY"},
+		{appStatSummary{Org: "acme", SpaceName: "prod", Space: "space-acme-prod"}, "PROD"},
+		{appStatSummary{Org: "acme", SpaceName: "staging", Space: "space-acme-staging"}, "ACME"},
+		{appStatSummary{Space: "space-acme-dev"}, "LEGACY"},
+		{appStatSummary{Org: "globex", SpaceName: "payments"}, "DEFAULT"},
+	} {
+		if got := jiraProjectFor(projects, test.app, "DEFAULT"); got != test.want {
+			t.Errorf("%+v filed into %s, want %s", test.app, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// renderJUnit fails each app matching --fail-on, or exceeding
+// --offender-ratio when no expression is given.
+func renderJUnit(w io.Writer, opts *options, appStats []appStatSummary) error {
+
+	policy := opts.failOnPolicy
+	description := fmt.Sprintf("--fail-on %s", opts.failOn)
+	if policy == nil {
+		policy = func(app *appStatSummary) bool { return app.Ratio > opts.offenderRatio }
+		description = fmt.Sprintf("ratio above %.1f", opts.offenderRatio)
+	}
+
+	suite := junitSuite{Name: "hall-of-shame", Tests: len(appStats)}
+	for i := range appStats {
+		app := &appStats[i]
+		testCase := junitCase{Name: app.Name, ClassName: app.Org + "." + app.SpaceName}
+
+		if policy(app) {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s violates %s", app.Name, description),
+				Text: fmt.Sprintf("instances %d, alloc %s, avg use %s, ratio %.2f, waste %s",
+					app.Instances, formatSize(app.MemoryAlloc), formatSize(app.AvgMemoryUse), app.Ratio, formatSize(app.Waste())),
+			}
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/danhigham/hall-of-shame/pkg/collector"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+type logCacheReadResult struct {
+	Envelopes struct {
+		Batch []json.RawMessage `json:"batch"`
+	} `json:"envelopes"`
+}
+
+type logCacheClient struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// newLogCacheClient assumes the usual foundation layout, where Log Cache is
+// served from the API domain with "api." swapped for "log-cache.".
+func newLogCacheClient(cliConnection plugin.CliConnection) (*logCacheClient, error) {
+
+	api, err := cliConnection.ApiEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := cliConnection.AccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	insecure, _ := cliConnection.IsSSLDisabled()
+
+	return &logCacheClient{
+		endpoint: strings.Replace(strings.TrimSuffix(api, "/"), "://api.", "://log-cache.", 1),
+		token:    token,
+		client:   collector.NewClient(insecure),
+	}, nil
+}
+
+// hasRequests reports whether gorouter emitted any HTTP timer envelope for
+// the app since the given time.
+func (client *logCacheClient) hasRequests(guid string, since time.Time) (bool, error) {
+
+	query := url.Values{}
+	query.Set("envelope_types", "TIMER")
+	query.Set("start_time", fmt.Sprintf("%d", since.UnixNano()))
+	query.Set("limit", "1")
+
+	body, err := client.get("/api/v1/read/"+guid, query)
+	if err != nil {
+		return false, err
+	}
+
+	res := logCacheReadResult{}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return false, err
+	}
+
+	return len(res.Envelopes.Batch) > 0, nil
+}
+
+type logCacheQueryResult struct {
+	Data struct {
+		Result []struct {
+			Value  []interface{}   `json:"value"`
+			Values [][]interface{} `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+type ratePoint struct {
+	At   time.Time
+	Rate float64
+}
+
+func (client *logCacheClient) get(path string, query url.Values) ([]byte, error) {
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s?%s", client.endpoint, path, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", client.token)
+
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("log cache request %s failed: %s %s", path, resp.Status, body)
+	}
+
+	return body, nil
+}
+
+// requestRate returns the app's HTTP requests per second over the last five
+// minutes, as seen by gorouter.
+func (client *logCacheClient) requestRate(guid string) (float64, error) {
+	return client.instantQuery(fmt.Sprintf(`sum(rate(http{source_id="%s"}[5m]))`, guid))
+}
+
+// requestRateSeries returns the app's hourly request rate between start and
+// end, bounded in practice by how much Log Cache retains.
+func (client *logCacheClient) requestRateSeries(guid string, start time.Time, end time.Time) ([]ratePoint, error) {
+
+	query := url.Values{}
+	query.Set("query", fmt.Sprintf(`sum(rate(http{source_id="%s"}[1h]))`, guid))
+	query.Set("start", fmt.Sprintf("%d", start.Unix()))
+	query.Set("end", fmt.Sprintf("%d", end.Unix()))
+	query.Set("step", "3600")
+
+	body, err := client.get("/api/v1/query_range", query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := logCacheQueryResult{}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Data.Result) == 0 {
+		return nil, nil
+	}
+
+	var points []ratePoint
+	for _, sample := range res.Data.Result[0].Values {
+		if len(sample) < 2 {
+			continue
+		}
+		at, ok := sample[0].(float64)
+		value, isString := sample[1].(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("unexpected log cache sample for %s", guid)
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, ratePoint{At: time.Unix(int64(at), 0), Rate: rate})
+	}
+
+	return points, nil
+}
+
+func (hallOfShame *HallOfShame) resolveRequestRates(cliConnection plugin.CliConnection, appStats []appStatSummary) error {
+
+	client, err := newLogCacheClient(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	wg := sizedwaitgroup.New(2)
+	for i := range appStats {
+		wg.Add()
+
+		go func(app *appStatSummary) {
+			defer wg.Done()
+
+			if rps, err := client.requestRate(app.GUID); err == nil {
+				app.RPS = rps
+			}
+		}(&appStats[i])
+	}
+
+	wg.Wait()
+	return nil
+}
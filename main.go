@@ -1,136 +1,608 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"sort"
 	"strings"
-	"time"
+	"sync"
 
 	"code.cloudfoundry.org/cli/plugin"
+	"github.com/danhigham/hall-of-shame/pkg/analyze"
+	"github.com/danhigham/hall-of-shame/pkg/collector"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/remeh/sizedwaitgroup"
 	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
-type statTime struct {
-	time.Time
-}
+const (
+	recommendedHeadroom = analyze.RecommendedHeadroom
+	recommendedStep     = analyze.RecommendedStep
+)
 
-type appStatSummary struct {
-	Name         string
-	GUID         string
-	Space        string
-	Instances    int
-	MemoryAlloc  int
-	AvgMemoryUse int
-	Ratio        float64
-}
+type appStatSummary = analyze.AppSummary
 
-type byRatio []appStatSummary
+type byRatio = analyze.ByRatio
 
-func (s *appStatSummary) toValueList() []string {
-	return []string{s.Name, s.Space, fmt.Sprintf("%d", s.MemoryAlloc), fmt.Sprintf("%d", s.AvgMemoryUse), fmt.Sprintf("%f", s.Ratio)}
+type byUtilization = analyze.ByUtilization
+
+func toValueList(s *appStatSummary) []string {
+	return []string{s.Name, s.Space, tableNumbers.int(s.MemoryAlloc), tableNumbers.int(s.AvgMemoryUse), tableNumbers.float(s.Ratio)}
 }
 
-func (a byRatio) Len() int           { return len(a) }
-func (a byRatio) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byRatio) Less(i, j int) bool { return a[j].Ratio < a[i].Ratio }
+type (
+	AppSearchResults  = collector.AppSearchResults
+	AppSearchResoures = collector.AppSearchResoures
+	AppSearchMetaData = collector.AppSearchMetaData
+	AppSearchEntity   = collector.AppSearchEntity
+	SpaceResource     = collector.SpaceResource
+	OrgResource       = collector.OrgResource
+	AppStat           = collector.AppStat
+)
 
-type AppSearchResults struct {
-	Resources []*AppSearchResoures `json:"resources"`
+type HallOfShame struct {
+	controller CloudController
 }
 
-type AppSearchResoures struct {
-	Metadata *AppSearchMetaData `json:"metadata"`
-	Entity   *AppSearchEntity   `json:"entity"`
+var subcommands = map[string]bool{
+	"history":             true,
+	"daemon":              true,
+	"serve-ui":            true,
+	"usage":               true,
+	"showback":            true,
+	"cells":               true,
+	"fds":                 true,
+	"danger":              true,
+	"healthchecks":        true,
+	"java":                true,
+	"staging":             true,
+	"idle":                true,
+	"autoscaler-policies": true,
+	"off-hours":           true,
+	"duplicates":          true,
+	"blobstore":           true,
+	"revisions":           true,
+	"env":                 true,
+	"explain":             true,
+	"compare":             true,
+	"compare-foundations": true,
+	"update":              true,
 }
 
-type AppSearchMetaData struct {
-	Guid string `json:"guid"`
-	Url  string `json:"url"`
-}
+func (hallOfShame *HallOfShame) Run(cliConnection plugin.CliConnection, args []string) {
+
+	if len(args) > 0 {
+		args = args[1:]
+	}
+
+	command := ""
+	if len(args) > 0 && subcommands[args[0]] {
+		command, args = args[0], args[1:]
+	}
+
+	opts, err := parseOptions(args)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if opts.version {
+		renderVersion(os.Stdout)
+		return
+	}
+
+	if opts.fixture != "" {
+		fixture, err := loadFixture(opts.fixture)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		cliConnection = fixture
+	}
+	if opts.replay != "" {
+		recording, err := loadRecording(opts.replay)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		cliConnection = recording
+	}
+	if opts.record != "" {
+		recording, err := newRecordingConnection(cliConnection, opts.record)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		cliConnection = recording
+	}
+	cliConnection = meteredConnection{cliConnection}
+
+	if opts.nativeHTTP {
+		controller, err := newHTTPController(cliConnection)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		hallOfShame.controller = controller
+	}
+
+	if opts.ci {
+		progressOutput = ioutil.Discard
+		diagnosticOutput = os.Stderr
+	}
+	collectMetadata(cliConnection)
+	userAccess = hallOfShame.detectAccess(cliConnection)
+	reportMeta.Scope = userAccess.reportScope()
+	configureNameCache(opts)
+	if err := configureRates(cliConnection, opts); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	tableNumbers = newNumberFormat(opts.locale, opts.decimals)
+
+	switch command {
+	case "history":
+		if err := runHistoryCommand(opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "daemon":
+		if err := hallOfShame.runDaemon(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "serve-ui":
+		if err := serveUI(opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "usage":
+		if err := hallOfShame.runUsageCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "showback":
+		if err := hallOfShame.runShowbackCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "cells":
+		if err := hallOfShame.runCellsCommand(cliConnection); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "fds":
+		if err := hallOfShame.runFdsCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "danger":
+		if err := hallOfShame.runDangerCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "healthchecks":
+		if err := hallOfShame.runHealthChecksCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "java":
+		if err := hallOfShame.runJavaCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "staging":
+		if err := hallOfShame.runStagingCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "idle":
+		if err := hallOfShame.runIdleCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "autoscaler-policies":
+		if err := hallOfShame.runPoliciesCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "off-hours":
+		if err := hallOfShame.runOffHoursCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "duplicates":
+		if err := hallOfShame.runDuplicatesCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "blobstore":
+		if err := hallOfShame.runBlobstoreCommand(cliConnection); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "revisions":
+		if err := hallOfShame.runRevisionsCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "env":
+		if err := hallOfShame.runEnvCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "explain":
+		if err := hallOfShame.runExplainCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "compare":
+		if err := hallOfShame.runCompareCommand(cliConnection, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "compare-foundations":
+		if err := runFoundationsCommand(opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	case "update":
+		if err := runUpdateCommand(cliConnection); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	telemetry := startTelemetry(opts, command)
+
+	updateNotice := func(io.Writer) {}
+	if !opts.ci && !opts.noUpdateCheck {
+		updateNotice = startUpdateCheck()
+	}
+
+	var appStats []appStatSummary
+
+	if opts.load != "" {
+		snap, err := loadSnapshot(opts.load)
+		if err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+			os.Exit(1)
+		}
+		appStats = snap.Apps
+		opts.history = false
+	} else {
+		appStats, err = hallOfShame.collect(cliConnection, opts)
+		if err != nil {
+			telemetry.finish(0, 0, err)
+			fmt.Fprintln(diagnosticOutput, err)
+			os.Exit(1)
+		}
+	}
+	collected := len(appStats)
+	unfiltered := appStats
+
+	if opts.save != "" {
+		if err := saveSnapshot(opts.save, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	appStats = applyFilters(opts, appStats)
+	setAppsManagerLinks(opts, appStats)
+
+	if opts.sortBy == "util" {
+		sort.Stable(byUtilization(appStats))
+	} else {
+		sort.Stable(byRatio(appStats))
+	}
+
+	if opts.ci {
+		hallOfShame.publish(opts, appStats, unfiltered)
+		code := runCI(os.Stdout, opts, appStats)
+		telemetry.finish(collected, len(appStats), nil)
+		os.Exit(code)
+	}
+
+	out := newPagedOutput(opts)
+
+	if opts.output == "gha-summary" {
+		if err := writeStepSummary(opts, appStats); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.diff != "" && opts.output != "gha-summary" {
+		previous, err := loadPrevious(opts, opts.diff)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		renderDiff(out, diffRuns(previous, appStats, opts.offenderRatio))
+	} else if err := renderReport(out, opts, appStats); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if opts.simulateRightsize {
+		renderSimulation(out, appStats)
+	}
+
+	if opts.chart && opts.output == "table" {
+		renderWasteChart(out, appStats, opts.top)
+	}
+
+	if opts.breakdown && opts.output == "table" {
+		renderAllocationBreakdown(out, appStats)
+	}
+
+	if opts.output == "table" {
+		renderAccessNote(out, userAccess)
+		renderAccessDenied(out, reportMeta.AccessDenied)
+	} else {
+		renderAccessNote(progressOutput, userAccess)
+		renderAccessDenied(progressOutput, reportMeta.AccessDenied)
+	}
+
+	if opts.output == "table" && userAccess.Admin {
+		if capacity, err := fetchPlatformCapacity(cliConnection); err == nil {
+			renderCapacity(out, capacity, appStats)
+		}
+	}
+
+	if opts.orgBudgets != "" && opts.output == "table" {
+		statuses, err := checkBudgets(opts, unfiltered)
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			renderBudgets(out, statuses, costRates.currency(opts.currency))
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		fmt.Println(err)
+	}
+	apiUsage.summary(progressOutput)
+	updateNotice(os.Stderr)
+	telemetry.finish(collected, len(appStats), nil)
+
+	if opts.perTeam != "" {
+		if err := writeTeamReports(opts, appStats); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	hallOfShame.publish(opts, appStats, unfiltered)
+
+	if opts.baseline != "" {
+		regressed, err := checkBaseline(os.Stdout, opts, appStats)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if regressed && opts.failOnRegression {
+			os.Exit(1)
+		}
+	}
+
+	if opts.failOnPolicy != nil {
+		if violations := policyViolations(opts.failOnPolicy, appStats); len(violations) > 0 {
+			renderViolations(os.Stdout, opts.failOn, violations)
+			os.Exit(1)
+		}
+	}
+
+	if opts.regoPolicy != "" {
+		results, err := evaluateRego(opts, appStats)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println()
+		if denied := renderRegoResults(os.Stdout, appStats, results); denied > 0 {
+			os.Exit(1)
+		}
+	}
 
-type AppSearchEntity struct {
-	Name      string `json:"name"`
-	Instances int    `json:"instances"`
-	SpaceGuid string `json:"space_guid"`
 }
 
-type AppStat struct {
-	State        string `json:"state"`
-	IsolationSeg string `json:"isolation_segment"`
-	Stats        struct {
-		Name      string   `json:"name"`
-		Uris      []string `json:"uris"`
-		Host      string   `json:"host"`
-		Port      int      `json:"port"`
-		Uptime    int      `json:"uptime"`
-		MemQuota  int      `json:"mem_quota"`
-		DiskQuota int      `json:"disk_quota"`
-		FdsQuota  int      `json:"fds_quota"`
-		Usage     struct {
-			Time statTime `json:"time"`
-			CPU  float64  `json:"cpu"`
-			Mem  int      `json:"mem"`
-			Disk int      `json:"disk"`
-		} `json:"usage"`
-	} `json:"stats"`
+// publish sends the report to every configured sink. Budgets are checked
+// against unfiltered, everything collected, since filters narrow the report
+// but not what an org holds.
+func (hallOfShame *HallOfShame) publish(opts *options, appStats []appStatSummary, unfiltered []appStatSummary) {
+
+	if opts.textfile != "" {
+		if err := writeTextfile(opts.textfile, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	if opts.history {
+		if err := recordHistory(opts, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	if uploaders := newUploaders(opts); len(uploaders) > 0 {
+		if err := uploadReports(uploaders, opts, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	if opts.jira {
+		if err := fileJiraIssues(opts, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	if opts.githubRepo != "" {
+		if err := fileGitHubIssues(opts, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	if opts.grafanaURL != "" {
+		if err := postGrafanaAnnotation(opts, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	if opts.pagerDutyKey != "" {
+		if err := notifyPagerDuty(opts, appStats, unfiltered); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
+
+	if opts.orgBudgets != "" && opts.grafanaURL != "" {
+		if err := postBudgetAnnotations(opts, unfiltered); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
 }
 
-type HallOfShame struct{}
+// progressOutput is where progress bars draw; --ci discards them.
+var progressOutput io.Writer = os.Stderr
 
-func (hallOfShame *HallOfShame) Run(cliConnection plugin.CliConnection, args []string) {
+// diagnosticOutput is where collection and publishing report errors they
+// carry on past; --ci moves them to stderr so stdout is only the summary.
+var diagnosticOutput io.Writer = os.Stdout
+
+func newProgressBar(total int) *pb.ProgressBar {
+	bar := pb.New(total)
+	bar.Output = progressOutput
+	return bar
+}
+
+func (hallOfShame *HallOfShame) collect(cliConnection plugin.CliConnection, opts *options) ([]appStatSummary, error) {
 
 	var appStats []appStatSummary
+	var denied []deniedApp
+	var mutex sync.Mutex
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	stacks, err := hallOfShame.GetStacks(cliConnection)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := hallOfShame.loadAppInventory(cliConnection, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	res, err := hallOfShame.GetAllApps(cliConnection)
+	checkpoint, err := openCheckpoint(opts)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	for _, app := range res.Resources {
+		if _, ok := resolvedNames.lookup(resolvedNames.Spaces, app.Entity.SpaceGuid); !ok {
+			hallOfShame.prefetchNames(cliConnection, opts)
+			break
+		}
 	}
 
-	bar := pb.StartNew(len(res.Resources))
+	bar := newProgressBar(len(res.Resources))
+	bar.Start()
 
 	wg := sizedwaitgroup.New(2)
 	for _, app := range res.Resources {
 
+		if rows, ok := checkpoint.completed(app.Metadata.Guid); ok {
+			mutex.Lock()
+			appStats = append(appStats, rows...)
+			mutex.Unlock()
+			bar.Increment()
+			continue
+		}
+
 		wg.Add()
 
 		go func(cfApp *AppSearchResoures, pb *pb.ProgressBar) {
 			defer wg.Done()
 
-			stats, err := hallOfShame.GetAppStats(cliConnection, cfApp.Metadata.Guid)
-			pb.Increment()
-
-			if err != nil {
-				return
+			stat := collector.Summarize(cfApp)
+
+			if cfApp.Entity.State == "STOPPED" {
+				pb.Increment()
+
+				if !opts.includeStopped {
+					return
+				}
+
+				stat.MemoryAlloc = cfApp.Entity.Memory << 20
+			} else {
+				stats, err := hallOfShame.GetAppStats(cliConnection, cfApp.Metadata.Guid)
+				pb.Increment()
+
+				if _, limited := err.(rateLimitError); limited {
+					fmt.Fprintf(progressOutput, "Skipping %s: %v\n", cfApp.Entity.Name, err)
+				}
+				if _, forbidden := err.(forbiddenError); forbidden {
+					spaceNames := names.resolve(cfApp.Entity.SpaceGuid)
+					mutex.Lock()
+					denied = append(denied, deniedApp{Name: cfApp.Entity.Name, GUID: cfApp.Metadata.Guid, Space: spaceNames.space, Org: spaceNames.org})
+					mutex.Unlock()
+				}
+				if err != nil {
+					return
+				}
+
+				if len(stats) == 0 {
+					return
+				}
+
+				collector.ApplyStats(&stat, stats)
+				if len(opts.states) == 0 && !collector.AnyRunning(stats) {
+					return
+				}
 			}
 
-			if stats["0"].State != "RUNNING" {
-				return
-			}
+			stat.Stack = stacks[cfApp.Entity.StackGuid]
+			spaceNames := names.resolve(cfApp.Entity.SpaceGuid)
+			stat.SpaceName, stat.Org, stat.OrgGUID = spaceNames.space, spaceNames.org, spaceNames.orgGuid
 
-			memAlloc := stats["0"].Stats.MemQuota
-
-			var totalUsage int
-			for _, stat := range stats {
-				totalUsage += stat.Stats.Usage.Mem
+			rows := []appStatSummary{stat}
+			if opts.processes && cfApp.Entity.State != "STOPPED" {
+				if split, err := hallOfShame.processBreakdown(cliConnection, stat); err == nil {
+					rows = split
+				}
 			}
 
-			stat := appStatSummary{
-				Name:         cfApp.Entity.Name,
-				GUID:         cfApp.Metadata.Guid,
-				Instances:    cfApp.Entity.Instances,
-				MemoryAlloc:  memAlloc,
-				Space:        cfApp.Entity.SpaceGuid,
-				AvgMemoryUse: totalUsage / len(stats),
-				Ratio:        float64(memAlloc) / float64(totalUsage/len(stats)),
-			}
-
-			appStats = append(appStats, stat)
+			mutex.Lock()
+			appStats = append(appStats, rows...)
+			mutex.Unlock()
+			checkpoint.record(cfApp.Metadata.Guid, rows)
 
 		}(app, bar)
 
@@ -140,68 +612,218 @@ func (hallOfShame *HallOfShame) Run(cliConnection plugin.CliConnection, args []s
 
 	bar.FinishPrint("Done!")
 
-	sort.Sort(byRatio(appStats))
+	sort.Slice(denied, func(i, j int) bool {
+		if denied[i].Org != denied[j].Org {
+			return denied[i].Org < denied[j].Org
+		}
+		if denied[i].Space != denied[j].Space {
+			return denied[i].Space < denied[j].Space
+		}
+		return denied[i].Name < denied[j].Name
+	})
+	reportMeta.AccessDenied = denied
+
+	if err := checkpoint.finish(); err != nil {
+		fmt.Fprintln(diagnosticOutput, err)
+	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name", "Space", "Alloc", "AvgUse", "Ratio"})
+	if err := resolvedNames.save(); err != nil {
+		fmt.Fprintln(diagnosticOutput, err)
+	}
 
-	for _, v := range appStats {
-		table.Append(v.toValueList())
+	if opts.owners {
+		hallOfShame.resolveOwners(cliConnection, appStats, opts.offenderRatio)
 	}
 
-	table.Render()
+	markVenerable(appStats)
+	markQuotaOutliers(appStats, opts.quotaOutlier)
+	markMisalignedQuotas(appStats, opts.quotaStep)
 
-}
+	if containsString(opts.columns, "pushed-by") {
+		hallOfShame.resolvePushers(cliConnection, appStats)
+	}
 
-func (hallOfShame *HallOfShame) GetAppStats(cliConnection plugin.CliConnection, appGuid string) (map[string]AppStat, error) {
+	if containsString(opts.columns, "last-crash") {
+		hallOfShame.resolveCrashes(cliConnection, appStats)
+	}
 
-	appQuery := fmt.Sprintf("/v2/apps/%v/stats", appGuid)
-	cmd := []string{"curl", appQuery}
+	if opts.excludeAutoscaled || containsString(opts.columns, "autoscaled") {
+		if err := hallOfShame.markAutoscaled(cliConnection, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
 
-	output, _ := cliConnection.CliCommandWithoutTerminalOutput(cmd...)
+	if containsString(opts.columns, "tasks") {
+		if err := hallOfShame.resolveTaskUsage(cliConnection, appStats, opts.tasksWindow); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
 
-	buffer := new(bytes.Buffer)
-	if err := json.Compact(buffer, []byte(strings.Join(output, ""))); err != nil {
-		fmt.Println(err)
+	if containsString(opts.columns, "rps") {
+		if err := hallOfShame.resolveRequestRates(cliConnection, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
 	}
 
-	// fmt.Printf("********\n%s\n\n%+v\n*********\n\n", appQuery, buffer)
-	statResult := map[string]AppStat{}
-	err := json.Unmarshal([]byte(strings.Join(output, "")), &statResult)
+	if opts.perTeam != "" || opts.jira || containsString(opts.columns, "team") {
+		if err := hallOfShame.assignTeams(cliConnection, opts, appStats); err != nil {
+			fmt.Fprintln(diagnosticOutput, err)
+		}
+	}
 
-	return statResult, err
+	return appStats, nil
 }
 
-func (hallOfShame *HallOfShame) GetAllApps(cliConnection plugin.CliConnection) (AppSearchResults, error) {
+func (hallOfShame *HallOfShame) GetSpace(cliConnection plugin.CliConnection, spaceGuid string) (SpaceResource, error) {
 
-	appQuery := fmt.Sprintf("/v2/apps")
-	cmd := []string{"curl", appQuery}
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", fmt.Sprintf("/v2/spaces/%v", spaceGuid))
+	res := SpaceResource{}
+	if err != nil {
+		return res, err
+	}
 
-	output, _ := cliConnection.CliCommandWithoutTerminalOutput(cmd...)
-	res := AppSearchResults{}
-	json.Unmarshal([]byte(strings.Join(output, "")), &res)
+	err = json.Unmarshal([]byte(strings.Join(output, "")), &res)
+	return res, err
+}
+
+func (hallOfShame *HallOfShame) GetOrg(cliConnection plugin.CliConnection, orgGuid string) (OrgResource, error) {
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", fmt.Sprintf("/v2/organizations/%v", orgGuid))
+	res := OrgResource{}
+	if err != nil {
+		return res, err
+	}
+
+	err = json.Unmarshal([]byte(strings.Join(output, "")), &res)
+	return res, err
+}
+
+func (hallOfShame *HallOfShame) GetStacks(cliConnection plugin.CliConnection) (map[string]string, error) {
 
-	return res, nil
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", "/v2/stacks?results-per-page=100")
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Resources []struct {
+			Metadata *AppSearchMetaData `json:"metadata"`
+			Entity   struct {
+				Name string `json:"name"`
+			} `json:"entity"`
+		} `json:"resources"`
+	}{}
+	if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+		return nil, err
+	}
+
+	stacks := map[string]string{}
+	for _, stack := range res.Resources {
+		stacks[stack.Metadata.Guid] = stack.Entity.Name
+	}
+
+	return stacks, nil
 }
 
 func (hallOfShame *HallOfShame) GetMetadata() plugin.PluginMetadata {
 	return plugin.PluginMetadata{
-		Name: "HallOfShame",
-		Version: plugin.VersionType{
-			Major: 0,
-			Minor: 1,
-			Build: 1,
-		},
+		Name:    "HallOfShame",
+		Version: pluginVersion,
 		Commands: []plugin.Command{
 			{
 				Name:     "Memory Hall of Shame",
 				Alias:    "hall-of-shame",
 				HelpText: "Reviews memory usages by  orgs and space. To obtain more information use --help",
 				UsageDetails: plugin.Usage{
-					Usage: "hall-of-shame - list memory in use by org and space.\n   cf memshame [-org] [-space]",
+					Usage: "hall-of-shame - list memory in use by org and space.\n   cf memshame [-org] [-space]\n   cf hall-of-shame history <app-name>\n   cf hall-of-shame history prune --history-retain 90d\n   cf hall-of-shame daemon --schedule \"0 6 * * MON\"\n   cf hall-of-shame serve-ui [--listen :8080]\n   cf hall-of-shame usage [--window 30d]\n   cf hall-of-shame showback --rate-gb-hour 0.02|--rates rates.csv [--currency USD] [--output csv]\n   cf hall-of-shame cells\n   cf hall-of-shame fds [--fds-threshold 80]\n   cf hall-of-shame danger [--danger-threshold 90]\n   cf hall-of-shame healthchecks [--max-health-timeout 2m]\n   cf hall-of-shame java\n   cf hall-of-shame staging [--staging-ratio 2]\n   cf hall-of-shame idle [--idle-for 7d]\n   cf hall-of-shame autoscaler-policies [--policy-dir DIR]\n   cf hall-of-shame off-hours [--nonprod-spaces dev*,test*] [--business-hours 08-18]\n   cf hall-of-shame duplicates\n   cf hall-of-shame blobstore\n   cf hall-of-shame revisions [--min-revisions 5]\n   cf hall-of-shame env [--env-threshold 256K]\n   cf hall-of-shame explain <app-name> [--samples 3]\n   cf hall-of-shame compare --org A --org B\n   cf hall-of-shame compare-foundations prod=prod.json staging=staging.json\n   cf hall-of-shame update",
 					Options: map[string]string{
-						"org":   "Specify the org to report",
-						"space": "Specify the space to report (requires -org)",
+						"org":                "Only report apps in these orgs; repeat or comma separate (compare takes two or more)",
+						"space":              "Specify the space to report (requires -org)",
+						"pagerduty-key":      "PagerDuty Events v2 routing key (or $PAGERDUTY_ROUTING_KEY)",
+						"pagerduty-waste":    "Open an incident when platform-wide waste exceeds this size",
+						"pagerduty-ratio":    "Open an incident for each app whose ratio exceeds this",
+						"offender-ratio":     "Ratio above which an app is filed as an offender (default 2)",
+						"jira":               "File or update a Jira issue per offending app (see --jira-url, --jira-project)",
+						"jira-projects":      "JSON file of Jira project keys by team (--team-map/--team-label), \"org/space\" or org",
+						"github-repo":        "Open or update offender issues in this owner/repo (see --github-mode)",
+						"telemetry-endpoint": "Opt in to posting anonymous run statistics (duration, app counts, error class; no names or GUIDs) to this URL, or set $HALL_OF_SHAME_TELEMETRY_URL",
+						"grafana-url":        "Post a Grafana annotation summarising the run (see --grafana-token)",
+						"textfile":           "Also write OpenMetrics gauges to this .prom file, replaced atomically, for the node_exporter textfile collector",
+						"output":             "Report format: table, json, csv, openmetrics (Prometheus textfile format), junit (one test case per app, failing on --fail-on or --offender-ratio) or gha-summary (Markdown appended to $GITHUB_STEP_SUMMARY)",
+						"max-col-width":      "Truncate table cells longer than this many characters (default 40); JSON and CSV keep full values",
+						"wide":               "Never truncate or wrap table cells",
+						"no-pager":           "Never pipe long table output through $PAGER",
+						"version":            "Print the plugin version, git commit, build date and Go version",
+						"no-etags":           "In daemon mode, don't send If-None-Match to reuse unchanged CAPI responses",
+						"checkpoint":         "Record each finished app in this file so an interrupted scan can be continued with --resume",
+						"resume":             "Continue the scan recorded in --checkpoint, skipping apps it already finished",
+						"fixture":            "Run against recorded Cloud Controller responses in this file instead of a foundation, e.g. testdata/run.json",
+						"record":             "Save every Cloud Controller response of this run into a directory, for bug reports or offline analysis",
+						"replay":             "Run against a directory written by --record instead of a foundation",
+						"native-http":        "Fetch apps and stats over HTTP with the cf CLI's token instead of one cf curl per request",
+						"cache":              "Reuse the app list fetched within this period, e.g. 10m, and only refresh stats; handy while tuning filters and output",
+						"name-cache-ttl":     "How long resolved org and space names are reused, e.g. 1h (default); 0 keeps them until the process exits",
+						"name-cache":         "Persist resolved org and space names to this file so later runs skip the lookups",
+						"no-update-check":    "Skip the daily check for a newer hall-of-shame release",
+						"decimals":           "Decimal places for ratios in table and CSV output (default 2)",
+						"locale":             "Number formatting locale for tables, e.g. de_DE (default from $LC_ALL, $LC_NUMERIC or $LANG)",
+						"sort":               "Sort order: ratio (highest first, default) or util (lowest Util% first)",
+						"save":               "Save the collected run to a snapshot file",
+						"load":               "Render a previously saved snapshot instead of querying the API",
+						"diff":               "Compare against a snapshot file or \"last\" (the previous --history run)",
+						"fail-on":            "Exit non-zero when any app matches an expression such as 'ratio>4 && waste>2G' (fields: ratio, alloc, use, waste, savings, instances, util, disk_ratio, bad_instances, rps, tasks, age_days, name, space, org, state, stack, team)",
+						"ci":                 "Concourse-friendly run: no progress bars, a one-line JSON summary on stdout, exit 2 on --fail-on matches and 3 on baseline regressions",
+						"ci-output":          "Directory --ci writes the full JSON report to",
+						"rego":               "Rego policy evaluated per app with the opa binary; deny messages fail the run, warn messages are printed",
+						"rego-package":       "Package holding the deny and warn rules (default hallofshame)",
+						"baseline":           "Compare total waste against an accepted baseline file (see --fail-on-regression, --update-baseline)",
+						"isolation-segment":  "Only report apps placed in this isolation segment (\"shared\" for the default)",
+						"stack":              "Only report apps on this stack, e.g. cflinuxfs4",
+						"apps-manager-url":   "Apps Manager base URL; adds a link to each app in JSON/CSV output and Jira/GitHub issues",
+						"older-than":         "Only report apps not created or updated within this period, e.g. 90d",
+						"min-alloc":          "Only report apps allocating at least this much memory per instance, e.g. 512M",
+						"min-instances":      "Only report apps with at least this many instances",
+						"name":               "Only report apps whose name matches this glob (payments-*) or /regex/",
+						"include-stopped":    "Also list stopped apps (usage 0) and add a State column",
+						"state":              "Only report apps in these derived states, e.g. CRASHED,STOPPED",
+						"columns":            "Extra table columns, comma separated: org, owners, team, pushed-by, stack, buildpack, route, routes, age, updated, state, bad-instances, last-crash, space-median, alignment, disk-ratio, util, rps, autoscaled, tasks, process",
+						"chart":              "Draw a bar chart of wasted memory per org (or per app with --top) after the table",
+						"top":                "With --chart, chart the N most wasteful apps instead of orgs",
+						"breakdown":          "Show each org's share of platform allocation, and how much of it is waste, as a proportional chart",
+						"simulate-rightsize": "Show the memory reclaimed if every recommendation were applied, by org and isolation segment",
+						"org-budgets":        "JSON file of per-org memory/cost budgets; breaches are flagged and sent to PagerDuty/Grafana",
+						"per-team":           "Write one report per team into this directory (see --team-label, --team-map)",
+						"owners":             "Look up SpaceDevelopers/SpaceManagers for offending apps",
+						"history":            "Record this run in ~/.hall-of-shame/history.db (see --history-db)",
+						"history-retain":     "Prune history runs older than this, e.g. 90d",
+						"schedule":           "Cron schedule for daemon mode, e.g. \"0 6 * * MON\"",
+						"window":             "Billing window for the usage command (default 30d)",
+						"rate-gb-hour":       "Price of one GB-hour of memory for the showback command",
+						"currency":           "Currency code for showback amounts (default USD)",
+						"rates":              "CSV or JSON file pricing memory GB-hours, disk GB-months and instances per foundation and isolation segment, used by showback and cost budgets",
+						"processes":          "Report each process type and sidecar separately using the v3 API",
+						"exclude-autoscaled": "Leave out apps bound to App Autoscaler",
+						"fds-threshold":      "Percentage of the descriptor quota at which the fds command flags an app (default 80)",
+						"staging-ratio":      "Multiple of runtime memory or disk at which the staging command flags an app's staging settings (default 2)",
+						"policy":             "JSON policy file; quota_step (e.g. \"256M\") sets the granularity the alignment column checks quotas against",
+						"quota-outlier":      "Multiple of the space's median quota above which an app is flagged in the space-median column (default 4)",
+						"max-health-timeout": "Health check timeout above which the healthchecks command flags an app (default 2m)",
+						"danger-threshold":   "Percentage of the memory quota at which the danger command flags an app's busiest instance (default 90)",
+						"idle-for":           "Period without HTTP requests after which the idle command lists an app (default 7d)",
+						"policy-dir":         "Directory the autoscaler-policies command writes policy JSON to (default autoscaler-policies)",
+						"nonprod-spaces":     "Space name globs the off-hours command considers, comma separated (default dev*,test*,qa*,sandbox*)",
+						"business-hours":     "Weekday business hours for the off-hours command, local time (default 08-18)",
+						"min-revisions":      "Revision or droplet count at which the revisions command lists an app (default 5)",
+						"tasks-window":       "How far back the tasks column counts one-off task memory (default 7d)",
+						"env-threshold":      "Environment JSON size at which the env command lists an app (default 256K)",
+						"samples":            "Number of stats samples the explain command takes, 5s apart (default 3)",
+						"listen":             "Address for the serve-ui dashboard, /api endpoints and Prometheus /metrics (default :8080)",
+						"s3-bucket":          "Upload JSON and CSV reports to this S3 bucket (see --archive-prefix, --s3-endpoint)",
+						"azure-container":    "Upload reports to this Azure Blob container (see --azure-account)",
+						"gcs-bucket":         "Upload reports to this Google Cloud Storage bucket",
+						"archive-dir":        "Write the JSON, CSV and OpenMetrics reports under this local directory, laid out like the bucket uploads",
+						"sign-key":           "PEM private key (RSA, ECDSA or Ed25519); archived and per-team reports get a .sha256 digest and a detached .sig signature",
+						"compress":           "Gzip archived and per-team reports; archives are named <foundation>-hall-of-shame-<timestamp>.<ext>.gz",
 					},
 				},
 			},
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type appGauge struct {
+	name  string
+	help  string
+	value func(*appStatSummary) float64
+}
+
+var appGauges = []appGauge{
+	{"hall_of_shame_memory_allocated_bytes", "Memory allocated per instance.", func(s *appStatSummary) float64 { return float64(s.MemoryAlloc) }},
+	{"hall_of_shame_memory_used_bytes", "Average memory used per instance.", func(s *appStatSummary) float64 { return float64(s.AvgMemoryUse) }},
+	{"hall_of_shame_memory_ratio", "Allocated over used memory; higher is more over-allocated.", func(s *appStatSummary) float64 { return s.Ratio }},
+	{"hall_of_shame_memory_waste_bytes", "Allocated but unused memory across all instances.", func(s *appStatSummary) float64 { return float64(s.Waste()) }},
+	{"hall_of_shame_memory_savings_bytes", "Memory freed across all instances by the recommended allocation.", func(s *appStatSummary) float64 { return float64(s.Savings()) }},
+}
+
+// registerMetrics serves the latest run in the Prometheus text format, for
+// the Metric Registrar to scrape once serve-ui runs as an app and is
+// registered with `cf register-metrics-endpoint`. Each series is labelled
+// with the app GUID as source_id, matching how Loggregator identifies apps,
+// so the figures can be joined with the app's own metrics.
+func registerMetrics(mux *http.ServeMux, store *historyStore) {
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		appStats, err := store.lastRun()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		renderMetrics(w, appStats)
+	})
+}
+
+func renderMetrics(w io.Writer, appStats []appStatSummary) {
+
+	for _, gauge := range appGauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", gauge.name, gauge.help, gauge.name)
+		for i := range appStats {
+			app := &appStats[i]
+			labels := fmt.Sprintf(`source_id="%s",app="%s",space="%s",org="%s"`,
+				metricLabel(app.GUID), metricLabel(app.Name), metricLabel(app.SpaceName), metricLabel(app.Org))
+			if app.ProcessType != "" {
+				labels += fmt.Sprintf(`,process_type="%s"`, metricLabel(app.ProcessType))
+			}
+			fmt.Fprintf(w, "%s{%s} %g\n", gauge.name, labels, gauge.value(app))
+		}
+	}
+}
+
+// renderOpenMetrics writes the node_exporter textfile collector format: the
+// per-app gauges plus totals and the time of the run, so a stale file left
+// by a failing cron job can be alerted on.
+func renderOpenMetrics(w io.Writer, appStats []appStatSummary) error {
+
+	renderMetrics(w, appStats)
+
+	var waste, savings int
+	for i := range appStats {
+		waste += appStats[i].Waste()
+		savings += appStats[i].Savings()
+	}
+
+	fmt.Fprintf(w, "# HELP hall_of_shame_apps Apps in the report.\n# TYPE hall_of_shame_apps gauge\nhall_of_shame_apps %d\n", len(appStats))
+	fmt.Fprintf(w, "# HELP hall_of_shame_waste_bytes Allocated but unused memory across all apps.\n# TYPE hall_of_shame_waste_bytes gauge\nhall_of_shame_waste_bytes %d\n", waste)
+	fmt.Fprintf(w, "# HELP hall_of_shame_savings_bytes Memory freed across all apps by the recommended allocations.\n# TYPE hall_of_shame_savings_bytes gauge\nhall_of_shame_savings_bytes %d\n", savings)
+	fmt.Fprintf(w, "# HELP hall_of_shame_last_run_timestamp_seconds When the report was collected.\n# TYPE hall_of_shame_last_run_timestamp_seconds gauge\nhall_of_shame_last_run_timestamp_seconds %d\n", time.Now().Unix())
+	_, err := fmt.Fprintln(w, "# EOF")
+	return err
+}
+
+// writeTextfile replaces path atomically, as the textfile collector may read
+// it at any moment.
+func writeTextfile(path string, appStats []appStatSummary) error {
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := renderOpenMetrics(tmp, appStats); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func metricLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
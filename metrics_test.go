@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsFromHistoryKeepProcessTypes(t *testing.T) {
+
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	appStats := []appStatSummary{
+		{GUID: "app-shop", Name: "shop", SpaceName: "prod", Org: "acme", ProcessType: "web", Instances: 2, MemoryAlloc: 1 << 30, AvgMemoryUse: 256 << 20, Ratio: 4},
+		{GUID: "app-shop", Name: "shop", SpaceName: "prod", Org: "acme", ProcessType: "worker", Instances: 1, MemoryAlloc: 512 << 20, AvgMemoryUse: 256 << 20, Ratio: 2},
+	}
+	if err := store.recordRun(time.Now(), appStats); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	registerMetrics(mux, store)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	seen := map[string]bool{}
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		series := line[:strings.LastIndex(line, " ")]
+		if seen[series] {
+			t.Errorf("duplicate series %s", series)
+		}
+		seen[series] = true
+	}
+
+	want := `hall_of_shame_memory_ratio{source_id="app-shop",app="shop",space="prod",org="acme",process_type="worker"}`
+	if !seen[want] {
+		t.Errorf("missing %s in:\n%s", want, recorder.Body)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+type spaceNames struct {
+	space   string
+	org     string
+	orgGuid string
+}
+
+type cachedName struct {
+	Name      string    `json:"name"`
+	OrgGuid   string    `json:"org_guid,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// nameCache outlives a single collection so daemon runs, and with
+// --name-cache later invocations, don't look up unchanged names again.
+type nameCache struct {
+	mutex sync.Mutex
+	ttl   time.Duration
+	path  string
+	dirty bool
+
+	Spaces map[string]cachedName `json:"spaces"`
+	Orgs   map[string]cachedName `json:"orgs"`
+}
+
+var resolvedNames = &nameCache{
+	ttl:    time.Hour,
+	Spaces: map[string]cachedName{},
+	Orgs:   map[string]cachedName{},
+}
+
+// configureNameCache applies --name-cache-ttl and loads --name-cache, if set.
+// An unreadable cache file is ignored and rebuilt on the next save.
+func configureNameCache(opts *options) {
+
+	resolvedNames.mutex.Lock()
+	defer resolvedNames.mutex.Unlock()
+
+	resolvedNames.ttl = opts.nameCacheTTL
+	resolvedNames.path = opts.nameCache
+	if resolvedNames.path == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(resolvedNames.path)
+	if err != nil {
+		return
+	}
+	loaded := nameCache{}
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return
+	}
+	for guid, name := range loaded.Spaces {
+		resolvedNames.Spaces[guid] = name
+	}
+	for guid, name := range loaded.Orgs {
+		resolvedNames.Orgs[guid] = name
+	}
+}
+
+func (cache *nameCache) fresh(entry cachedName) bool {
+	return cache.ttl <= 0 || time.Since(entry.FetchedAt) < cache.ttl
+}
+
+func (cache *nameCache) lookup(entries map[string]cachedName, guid string) (cachedName, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, ok := entries[guid]
+	return entry, ok && cache.fresh(entry)
+}
+
+func (cache *nameCache) store(entries map[string]cachedName, guid string, entry cachedName) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry.FetchedAt = time.Now().UTC()
+	entries[guid] = entry
+	cache.dirty = true
+}
+
+// save writes the cache back to --name-cache, dropping expired entries.
+func (cache *nameCache) save() error {
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.path == "" || !cache.dirty {
+		return nil
+	}
+
+	for _, entries := range []map[string]cachedName{cache.Spaces, cache.Orgs} {
+		for guid, entry := range entries {
+			if !cache.fresh(entry) {
+				delete(entries, guid)
+			}
+		}
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cache.path), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(cache.path, data, 0644); err != nil {
+		return err
+	}
+
+	cache.dirty = false
+	return nil
+}
+
+type nameResolver struct {
+	hallOfShame   *HallOfShame
+	cliConnection plugin.CliConnection
+	cache         *nameCache
+}
+
+func newNameResolver(hallOfShame *HallOfShame, cliConnection plugin.CliConnection) *nameResolver {
+	return &nameResolver{
+		hallOfShame:   hallOfShame,
+		cliConnection: cliConnection,
+		cache:         resolvedNames,
+	}
+}
+
+func (resolver *nameResolver) resolve(spaceGuid string) spaceNames {
+
+	var names spaceNames
+
+	space, ok := resolver.cache.lookup(resolver.cache.Spaces, spaceGuid)
+	if !ok {
+		res, err := resolver.hallOfShame.GetSpace(resolver.cliConnection, spaceGuid)
+		if err != nil {
+			return names
+		}
+		space = cachedName{Name: res.Entity.Name, OrgGuid: res.Entity.OrganizationGuid}
+		resolver.cache.store(resolver.cache.Spaces, spaceGuid, space)
+	}
+	names.space = space.Name
+	names.orgGuid = space.OrgGuid
+
+	org, ok := resolver.cache.lookup(resolver.cache.Orgs, names.orgGuid)
+	if !ok {
+		res, err := resolver.hallOfShame.GetOrg(resolver.cliConnection, names.orgGuid)
+		if err != nil {
+			return names
+		}
+		org = cachedName{Name: res.Entity.Name}
+		resolver.cache.store(resolver.cache.Orgs, names.orgGuid, org)
+	}
+	names.org = org.Name
+
+	return names
+}
+
+type V3IncludedNames struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Included struct {
+		Spaces []struct {
+			Guid          string `json:"guid"`
+			Name          string `json:"name"`
+			Relationships struct {
+				Organization struct {
+					Data struct {
+						Guid string `json:"guid"`
+					} `json:"data"`
+				} `json:"organization"`
+			} `json:"relationships"`
+		} `json:"spaces"`
+		Organizations []struct {
+			Guid string `json:"guid"`
+			Name string `json:"name"`
+		} `json:"organizations"`
+	} `json:"included"`
+}
+
+// prefetchNames fills the name cache from /v3/apps with the spaces and orgs
+// included inline, so a foundation with thousands of apps costs a few pages
+// instead of a space and org lookup per space. V2-only foundations fall back
+// to inline relations; if both fail resolve looks names up one by one.
+func (hallOfShame *HallOfShame) prefetchNames(cliConnection plugin.CliConnection, opts *options) error {
+	if err := hallOfShame.prefetchNamesV3(cliConnection); err != nil {
+		return hallOfShame.prefetchNamesV2(cliConnection, opts)
+	}
+	return nil
+}
+
+func (hallOfShame *HallOfShame) prefetchNamesV3(cliConnection plugin.CliConnection) error {
+
+	const v3Query = "/v3/apps?per_page=5000&include=space.organization"
+
+	query := v3Query
+	for query != "" {
+		res := V3IncludedNames{}
+		if err := hallOfShame.v3Get(cliConnection, query, &res); err != nil {
+			return err
+		}
+		if len(res.Included.Spaces) == 0 && len(res.Included.Organizations) == 0 && query == v3Query {
+			return fmt.Errorf("/v3/apps returned no included names")
+		}
+
+		for _, org := range res.Included.Organizations {
+			resolvedNames.store(resolvedNames.Orgs, org.Guid, cachedName{Name: org.Name})
+		}
+		for _, space := range res.Included.Spaces {
+			resolvedNames.store(resolvedNames.Spaces, space.Guid, cachedName{Name: space.Name, OrgGuid: space.Relationships.Organization.Data.Guid})
+		}
+
+		query = ""
+		if res.Pagination.Next != nil {
+			query = v3RequestPath(res.Pagination.Next.Href)
+		}
+	}
+
+	return nil
+}
+
+type V2InlineSpaces struct {
+	NextUrl   string `json:"next_url"`
+	Resources []struct {
+		Metadata *AppSearchMetaData `json:"metadata"`
+		Entity   struct {
+			Name             string `json:"name"`
+			OrganizationGuid string `json:"organization_guid"`
+			Organization     struct {
+				Entity struct {
+					Name string `json:"name"`
+				} `json:"entity"`
+			} `json:"organization"`
+		} `json:"entity"`
+	} `json:"resources"`
+}
+
+type V2InlineOrgs struct {
+	NextUrl   string `json:"next_url"`
+	Resources []struct {
+		Metadata *AppSearchMetaData `json:"metadata"`
+		Entity   struct {
+			Name   string `json:"name"`
+			Spaces []struct {
+				Metadata *AppSearchMetaData `json:"metadata"`
+				Entity   struct {
+					Name string `json:"name"`
+				} `json:"entity"`
+			} `json:"spaces"`
+		} `json:"entity"`
+	} `json:"resources"`
+}
+
+// prefetchNamesV2 joins orgs and spaces with inline-relations-depth. With
+// --org only those orgs are fetched, spaces inlined, via a q filter.
+func (hallOfShame *HallOfShame) prefetchNamesV2(cliConnection plugin.CliConnection, opts *options) error {
+
+	if len(opts.orgs) > 0 {
+		query := "/v2/organizations?inline-relations-depth=1&results-per-page=100&q=" + url.QueryEscape("name IN "+strings.Join(opts.orgs, ","))
+		for query != "" {
+			res := V2InlineOrgs{}
+			if err := hallOfShame.v3Get(cliConnection, query, &res); err != nil {
+				return err
+			}
+			for _, org := range res.Resources {
+				resolvedNames.store(resolvedNames.Orgs, org.Metadata.Guid, cachedName{Name: org.Entity.Name})
+				for _, space := range org.Entity.Spaces {
+					resolvedNames.store(resolvedNames.Spaces, space.Metadata.Guid, cachedName{Name: space.Entity.Name, OrgGuid: org.Metadata.Guid})
+				}
+			}
+			query = res.NextUrl
+		}
+		return nil
+	}
+
+	query := "/v2/spaces?inline-relations-depth=1&results-per-page=100"
+	for query != "" {
+		res := V2InlineSpaces{}
+		if err := hallOfShame.v3Get(cliConnection, query, &res); err != nil {
+			return err
+		}
+		for _, space := range res.Resources {
+			resolvedNames.store(resolvedNames.Spaces, space.Metadata.Guid, cachedName{Name: space.Entity.Name, OrgGuid: space.Entity.OrganizationGuid})
+			resolvedNames.store(resolvedNames.Orgs, space.Entity.OrganizationGuid, cachedName{Name: space.Entity.Organization.Entity.Name})
+		}
+		query = res.NextUrl
+	}
+	return nil
+}
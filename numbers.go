@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type numberFormat struct {
+	thousands string
+	decimal   string
+	decimals  int
+}
+
+// tableNumbers formats numbers in table output. JSON and CSV stay
+// machine-readable and only honour the decimal places.
+var tableNumbers = numberFormat{thousands: ",", decimal: ".", decimals: 2}
+
+var localeSeparators = map[string][2]string{
+	"en": {",", "."},
+	"de": {".", ","}, "es": {".", ","}, "it": {".", ","}, "nl": {".", ","},
+	"pt": {".", ","}, "da": {".", ","}, "id": {".", ","}, "tr": {".", ","},
+	"fr": {" ", ","}, "ru": {" ", ","}, "pl": {" ", ","}, "cs": {" ", ","},
+	"sv": {" ", ","}, "fi": {" ", ","}, "nb": {" ", ","}, "uk": {" ", ","},
+	"de_CH": {"'", "."},
+}
+
+// newNumberFormat picks separators from the given locale, falling back to
+// $LC_ALL, $LC_NUMERIC and $LANG, then to English.
+func newNumberFormat(locale string, decimals int) numberFormat {
+
+	for _, candidate := range []string{locale, os.Getenv("LC_ALL"), os.Getenv("LC_NUMERIC"), os.Getenv("LANG")} {
+		candidate = strings.SplitN(candidate, ".", 2)[0]
+		if candidate == "" || candidate == "C" || candidate == "POSIX" {
+			continue
+		}
+		if separators, ok := localeSeparators[candidate]; ok {
+			return numberFormat{separators[0], separators[1], decimals}
+		}
+		if separators, ok := localeSeparators[strings.SplitN(candidate, "_", 2)[0]]; ok {
+			return numberFormat{separators[0], separators[1], decimals}
+		}
+	}
+
+	return numberFormat{",", ".", decimals}
+}
+
+func (format numberFormat) int(value int) string {
+	digits := strconv.Itoa(value)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	return sign + format.group(digits)
+}
+
+func (format numberFormat) float(value float64) string {
+	text := strconv.FormatFloat(value, 'f', format.decimals, 64)
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+
+	parts := strings.SplitN(text, ".", 2)
+	grouped := format.group(parts[0])
+	if len(parts) == 2 {
+		grouped += format.decimal + parts[1]
+	}
+	return sign + grouped
+}
+
+func (format numberFormat) group(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var grouped strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		grouped.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if grouped.Len() > 0 {
+			grouped.WriteString(format.thousands)
+		}
+		grouped.WriteString(digits[i : i+3])
+	}
+	return grouped.String()
+}
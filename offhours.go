@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	offHoursLookback = 7 * 24 * time.Hour
+
+	// offHoursMaxShare is the largest fraction of weekly traffic an app may
+	// receive outside business hours and still be suggested for shutdown.
+	offHoursMaxShare = 0.05
+)
+
+type businessHours struct {
+	start int
+	end   int
+}
+
+func parseBusinessHours(value string) (businessHours, error) {
+	parts := strings.SplitN(value, "-", 2)
+	if len(parts) != 2 {
+		return businessHours{}, fmt.Errorf("invalid --business-hours %q, expected e.g. 08-18", value)
+	}
+
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return businessHours{}, fmt.Errorf("invalid --business-hours %q: %v", value, err)
+	}
+	end, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return businessHours{}, fmt.Errorf("invalid --business-hours %q: %v", value, err)
+	}
+	if start < 0 || end > 24 || start >= end {
+		return businessHours{}, fmt.Errorf("invalid --business-hours %q", value)
+	}
+
+	return businessHours{start, end}, nil
+}
+
+func (hours businessHours) contains(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return t.Hour() >= hours.start && t.Hour() < hours.end
+}
+
+func (hours businessHours) offHoursPerWeek() int {
+	return 7*24 - 5*(hours.end-hours.start)
+}
+
+type offHoursSuggestion struct {
+	app      appStatSummary
+	offShare float64
+	freed    int
+}
+
+func nonProdSpace(patterns []string, space string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, space); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (hallOfShame *HallOfShame) runOffHoursCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	hours, err := parseBusinessHours(opts.businessHours)
+	if err != nil {
+		return err
+	}
+
+	appStats, err := hallOfShame.collect(cliConnection, opts)
+	if err != nil {
+		return err
+	}
+	appStats = applyFilters(opts, appStats)
+
+	client, err := newLogCacheClient(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	end := time.Now()
+	start := end.Add(-offHoursLookback)
+
+	var suggestions []offHoursSuggestion
+	for _, app := range appStats {
+		if app.State == "STOPPED" || !nonProdSpace(opts.nonProdSpaces, app.SpaceName) {
+			continue
+		}
+
+		points, err := client.requestRateSeries(app.GUID, start, end)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		var total, off float64
+		for _, point := range points {
+			total += point.Rate
+			if !hours.contains(point.At) {
+				off += point.Rate
+			}
+		}
+
+		share := 0.0
+		if total > 0 {
+			share = off / total
+		}
+		if share <= offHoursMaxShare {
+			suggestions = append(suggestions, offHoursSuggestion{app, share, app.MemoryAlloc * app.Instances})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].freed > suggestions[j].freed })
+
+	renderOffHours(os.Stdout, suggestions, hours)
+	return nil
+}
+
+func renderOffHours(w io.Writer, suggestions []offHoursSuggestion, hours businessHours) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Instances", "Off-hours Traffic", "Frees"})
+
+	var freed int
+	for _, suggestion := range suggestions {
+		app := suggestion.app
+		table.Append([]string{
+			app.Name,
+			app.Org,
+			app.SpaceName,
+			fmt.Sprintf("%d", app.Instances),
+			fmt.Sprintf("%.1f%%", suggestion.offShare*100),
+			formatSize(suggestion.freed),
+		})
+		freed += suggestion.freed
+	}
+
+	table.Render()
+
+	gbHours := float64(freed) / (1 << 30) * float64(hours.offHoursPerWeek())
+	fmt.Fprintf(w, "\nStopping %d apps outside %02d:00-%02d:00 on weekdays frees %s, about %.0f GB-hours a week.\n",
+		len(suggestions), hours.start, hours.end, formatSize(freed), gbHours)
+}
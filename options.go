@@ -0,0 +1,520 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type options struct {
+	args []string
+
+	output   string
+	textfile string
+	columns  []string
+	sortBy   string
+
+	maxColWidth   int
+	wide          bool
+	noPager       bool
+	noUpdateCheck bool
+	version       bool
+	decimals      int
+	locale        string
+
+	orgs              []string
+	isolationSegment  string
+	stack             string
+	appsManagerURL    string
+	olderThan         time.Duration
+	minAlloc          int
+	minInstances      int
+	name              string
+	nameMatcher       func(string) bool
+	includeStopped    bool
+	excludeAutoscaled bool
+	processes         bool
+	states            []string
+	owners            bool
+
+	perTeam   string
+	teamLabel string
+	teamMap   string
+
+	save string
+	load string
+	diff string
+
+	baseline         string
+	failOnRegression bool
+	failOn           string
+	failOnPolicy     appPolicy
+	regoPolicy       string
+	regoPackage      string
+	ci               bool
+	ciOutput         string
+	updateBaseline   bool
+
+	schedule     string
+	listen       string
+	usageWindow  time.Duration
+	idleFor      time.Duration
+	nameCache    string
+	cache        time.Duration
+	noETags      bool
+	checkpoint   string
+	fixture      string
+	record       string
+	replay       string
+	nativeHTTP   bool
+	resume       bool
+	nameCacheTTL time.Duration
+	tasksWindow  time.Duration
+	rateGBHour   float64
+	currency     string
+	rates        string
+	orgBudgets   string
+
+	simulateRightsize bool
+	chart             bool
+	top               int
+	breakdown         bool
+	fdsThreshold      float64
+	dangerThreshold   float64
+	maxHealthTimeout  time.Duration
+	quotaOutlier      float64
+	policyFile        string
+	quotaStep         int
+	stagingRatio      float64
+	policyDir         string
+	minRevisions      int
+	envThreshold      int
+	samples           int
+	nonProdSpaces     []string
+	businessHours     string
+
+	history       bool
+	historyDB     string
+	historyRetain time.Duration
+
+	archivePrefix string
+	archiveDir    string
+	compress      bool
+	signKey       string
+	signer        *reportSigner
+
+	s3Bucket   string
+	s3Region   string
+	s3Endpoint string
+
+	azureAccount   string
+	azureContainer string
+
+	gcsBucket string
+
+	pagerDutyKey       string
+	pagerDutyWaste     int
+	pagerDutyRatio     float64
+	pagerDutyStateFile string
+
+	offenderRatio float64
+
+	jira             bool
+	jiraURL          string
+	jiraUser         string
+	jiraToken        string
+	jiraProject      string
+	jiraProjectsFile string
+	jiraIssueType    string
+
+	githubRepo   string
+	githubToken  string
+	githubAPIURL string
+	githubMode   string
+
+	grafanaURL          string
+	grafanaToken        string
+	grafanaDashboardUID string
+	grafanaTags         string
+
+	telemetryEndpoint string
+}
+
+func parseOptions(args []string) (*options, error) {
+	opts := &options{}
+
+	flags := flag.NewFlagSet("hall-of-shame", flag.ContinueOnError)
+	flags.StringVar(&opts.output, "output", "table", "report format: table, json, csv, openmetrics, junit or gha-summary")
+	flags.StringVar(&opts.textfile, "textfile", "", "also write OpenMetrics gauges to this .prom file for the node_exporter textfile collector")
+	flags.StringVar(&opts.sortBy, "sort", "ratio", "sort order: ratio (highest first) or util (lowest first)")
+	flags.IntVar(&opts.maxColWidth, "max-col-width", 40, "truncate table cells longer than this")
+	flags.IntVar(&opts.decimals, "decimals", 2, "decimal places for ratios")
+	flags.StringVar(&opts.locale, "locale", "", "number formatting locale for tables (e.g. de_DE)")
+	flags.BoolVar(&opts.noPager, "no-pager", false, "never pipe long table output through $PAGER")
+	flags.BoolVar(&opts.version, "version", false, "print version and build information")
+	flags.BoolVar(&opts.noUpdateCheck, "no-update-check", false, "skip the daily check for a newer release")
+	flags.BoolVar(&opts.wide, "wide", false, "never truncate or wrap table cells")
+	flags.Var((*listFlag)(&opts.columns), "columns", "extra table columns, comma separated")
+	flags.StringVar(&opts.appsManagerURL, "apps-manager-url", "", "Apps Manager base URL used to link each app")
+	flags.Var((*durationFlag)(&opts.olderThan), "older-than", "only report apps not created or updated within this period (e.g. 90d)")
+	flags.Var((*sizeFlag)(&opts.minAlloc), "min-alloc", "only report apps allocating at least this much memory per instance (e.g. 512M)")
+	flags.IntVar(&opts.minInstances, "min-instances", 0, "only report apps with at least this many instances")
+	flags.StringVar(&opts.name, "name", "", "only report apps whose name matches this glob, or /regex/")
+	flags.BoolVar(&opts.includeStopped, "include-stopped", false, "also list stopped apps with zero usage")
+	flags.BoolVar(&opts.processes, "processes", false, "report each process type and sidecar separately (v3 API)")
+	flags.BoolVar(&opts.excludeAutoscaled, "exclude-autoscaled", false, "leave out apps bound to App Autoscaler")
+	flags.Var((*listFlag)(&opts.states), "state", "only report apps in these states (RUNNING, STOPPED, CRASHED, ...)")
+	flags.StringVar(&opts.stack, "stack", "", "only report apps on this stack")
+	flags.Var((*listFlag)(&opts.orgs), "org", "only report apps in these orgs (repeatable)")
+	flags.StringVar(&opts.isolationSegment, "isolation-segment", "", "only report apps in this isolation segment (\"shared\" for the default)")
+	flags.BoolVar(&opts.owners, "owners", false, "look up space developers and managers for offending apps")
+
+	flags.StringVar(&opts.perTeam, "per-team", "", "write one report per team into this directory")
+	flags.StringVar(&opts.teamLabel, "team-label", "team", "v3 app label identifying the owning team")
+	flags.StringVar(&opts.teamMap, "team-map", "", "JSON file mapping app GUIDs, app names, org/space or orgs to teams")
+
+	flags.StringVar(&opts.save, "save", "", "save the collected run to this snapshot file")
+	flags.StringVar(&opts.load, "load", "", "load a saved snapshot instead of querying the API")
+	flags.StringVar(&opts.diff, "diff", "", "compare against a snapshot file, or \"last\" for the previous --history run")
+
+	flags.StringVar(&opts.baseline, "baseline", "", "accepted baseline file to compare waste against")
+	flags.StringVar(&opts.failOn, "fail-on", "", "exit non-zero when any app matches this expression (e.g. 'ratio>4 && waste>2G')")
+	flags.StringVar(&opts.regoPolicy, "rego", "", "Rego policy file with deny/warn rules evaluated per app (requires opa)")
+	flags.StringVar(&opts.regoPackage, "rego-package", "hallofshame", "package holding the deny and warn rules")
+	flags.BoolVar(&opts.ci, "ci", false, "CI mode: no progress output, JSON summary on stdout and policy exit codes")
+	flags.StringVar(&opts.ciOutput, "ci-output", "", "directory --ci writes the full JSON report to")
+	flags.BoolVar(&opts.failOnRegression, "fail-on-regression", false, "exit non-zero when waste grows beyond the baseline")
+	flags.BoolVar(&opts.updateBaseline, "update-baseline", false, "replace the baseline with this run")
+
+	flags.StringVar(&opts.schedule, "schedule", "", "cron schedule for daemon mode (e.g. \"0 6 * * MON\")")
+	flags.StringVar(&opts.listen, "listen", ":8080", "address for serve-ui to listen on")
+
+	opts.usageWindow = 30 * 24 * time.Hour
+	flags.Var((*durationFlag)(&opts.usageWindow), "window", "billing window for the usage command")
+	opts.tasksWindow = 7 * 24 * time.Hour
+	flags.Var((*durationFlag)(&opts.tasksWindow), "tasks-window", "how far back the tasks column counts task memory")
+	flags.StringVar(&opts.fixture, "fixture", "", "answer API requests from this recorded fixture instead of a foundation")
+	flags.StringVar(&opts.record, "record", "", "save every API response of this run into this directory")
+	flags.StringVar(&opts.replay, "replay", "", "answer API requests from a directory written by --record")
+	flags.BoolVar(&opts.nativeHTTP, "native-http", false, "fetch apps and stats over HTTP instead of cf curl")
+	flags.StringVar(&opts.checkpoint, "checkpoint", "", "record finished apps in this file so an interrupted scan can be resumed")
+	flags.BoolVar(&opts.resume, "resume", false, "continue the scan recorded in --checkpoint")
+	flags.BoolVar(&opts.noETags, "no-etags", false, "in daemon mode, don't make conditional requests with If-None-Match")
+	flags.Var((*durationFlag)(&opts.cache), "cache", "reuse the app list fetched within this period and only refresh stats")
+	opts.nameCacheTTL = time.Hour
+	flags.Var((*durationFlag)(&opts.nameCacheTTL), "name-cache-ttl", "how long resolved org and space names are reused")
+	flags.StringVar(&opts.nameCache, "name-cache", "", "persist resolved org and space names to this file between runs")
+	opts.idleFor = 7 * 24 * time.Hour
+	flags.Var((*durationFlag)(&opts.idleFor), "idle-for", "period without requests after which the idle command lists an app")
+	flags.Float64Var(&opts.rateGBHour, "rate-gb-hour", 0, "price of one GB-hour of memory for showback")
+	flags.StringVar(&opts.currency, "currency", "USD", "currency code for showback amounts")
+	flags.StringVar(&opts.rates, "rates", "", "CSV or JSON file of memory, disk and instance rates per foundation and isolation segment")
+	flags.Var((*listFlag)(&opts.nonProdSpaces), "nonprod-spaces", "space name globs considered by the off-hours command")
+	flags.StringVar(&opts.businessHours, "business-hours", "08-18", "weekday business hours for the off-hours command")
+	flags.IntVar(&opts.samples, "samples", 3, "number of stats samples the explain command takes")
+	opts.envThreshold = 256 << 10
+	flags.Var((*sizeFlag)(&opts.envThreshold), "env-threshold", "environment JSON size at which the env command lists an app")
+	flags.IntVar(&opts.minRevisions, "min-revisions", 5, "revision or droplet count at which the revisions command lists an app")
+	flags.StringVar(&opts.policyDir, "policy-dir", "autoscaler-policies", "directory for generated autoscaler policies")
+	flags.Float64Var(&opts.fdsThreshold, "fds-threshold", 80, "percentage of the descriptor quota flagged by the fds command")
+	flags.Float64Var(&opts.stagingRatio, "staging-ratio", 2, "multiple of runtime memory or disk flagged by the staging command")
+	flags.StringVar(&opts.policyFile, "policy", "", "JSON policy file, e.g. {\"quota_step\": \"256M\"}")
+	flags.Float64Var(&opts.quotaOutlier, "quota-outlier", 4, "multiple of the space's median quota flagged in the space-median column")
+	opts.maxHealthTimeout = 2 * time.Minute
+	flags.Var((*durationFlag)(&opts.maxHealthTimeout), "max-health-timeout", "health check timeout above which the healthchecks command flags an app")
+	flags.Float64Var(&opts.dangerThreshold, "danger-threshold", 90, "percentage of the memory quota at which the danger command flags an app")
+	flags.BoolVar(&opts.chart, "chart", false, "draw a bar chart of wasted memory per org after the table")
+	flags.IntVar(&opts.top, "top", 0, "with --chart, chart the N most wasteful apps instead of orgs")
+	flags.BoolVar(&opts.breakdown, "breakdown", false, "show each org's share of platform allocation and waste")
+	flags.BoolVar(&opts.simulateRightsize, "simulate-rightsize", false, "report the memory reclaimed if all recommendations were applied")
+	flags.StringVar(&opts.orgBudgets, "org-budgets", "", "JSON file of per-org memory and cost budgets")
+
+	flags.BoolVar(&opts.history, "history", false, "record this run in the local history database")
+	flags.StringVar(&opts.historyDB, "history-db", defaultStatePath("history.db"), "path of the local history database")
+	flags.Var((*durationFlag)(&opts.historyRetain), "history-retain", "prune history runs older than this (e.g. 90d)")
+
+	flags.StringVar(&opts.archivePrefix, "archive-prefix", "hall-of-shame", "key prefix for uploaded reports")
+	flags.StringVar(&opts.archivePrefix, "s3-prefix", "hall-of-shame", "alias for --archive-prefix")
+	flags.StringVar(&opts.archiveDir, "archive-dir", "", "write archived reports under this local directory")
+	flags.BoolVar(&opts.compress, "compress", false, "gzip archived and per-team reports")
+	flags.StringVar(&opts.signKey, "sign-key", "", "PEM private key used to sign archived and per-team reports")
+
+	flags.StringVar(&opts.s3Bucket, "s3-bucket", "", "upload reports to this S3 bucket")
+	flags.StringVar(&opts.s3Region, "s3-region", envOrDefault("AWS_REGION", "us-east-1"), "S3 region")
+	flags.StringVar(&opts.s3Endpoint, "s3-endpoint", "", "S3-compatible endpoint (defaults to AWS)")
+
+	flags.StringVar(&opts.azureAccount, "azure-account", os.Getenv("AZURE_STORAGE_ACCOUNT"), "upload reports to this Azure storage account")
+	flags.StringVar(&opts.azureContainer, "azure-container", "", "Azure Blob container for uploaded reports")
+
+	flags.StringVar(&opts.gcsBucket, "gcs-bucket", "", "upload reports to this Google Cloud Storage bucket")
+	flags.StringVar(&opts.pagerDutyKey, "pagerduty-key", os.Getenv("PAGERDUTY_ROUTING_KEY"), "PagerDuty Events v2 routing key")
+	flags.Var((*sizeFlag)(&opts.pagerDutyWaste), "pagerduty-waste", "open an incident when platform-wide waste exceeds this (e.g. 500G)")
+	flags.Float64Var(&opts.pagerDutyRatio, "pagerduty-ratio", 0, "open an incident for each app whose ratio exceeds this")
+	flags.StringVar(&opts.pagerDutyStateFile, "pagerduty-state", defaultStatePath("pagerduty.json"), "file tracking open PagerDuty incidents")
+
+	flags.Float64Var(&opts.offenderRatio, "offender-ratio", 2, "ratio above which an app is filed as an offender")
+
+	flags.BoolVar(&opts.jira, "jira", false, "file or update a Jira issue per offending app")
+	flags.StringVar(&opts.jiraURL, "jira-url", os.Getenv("JIRA_URL"), "Jira base URL")
+	flags.StringVar(&opts.jiraUser, "jira-user", os.Getenv("JIRA_USER"), "Jira user name")
+	flags.StringVar(&opts.jiraToken, "jira-token", os.Getenv("JIRA_API_TOKEN"), "Jira API token")
+	flags.StringVar(&opts.jiraProject, "jira-project", "", "default Jira project key")
+	flags.StringVar(&opts.jiraProjectsFile, "jira-projects", "", "JSON file mapping a team, \"org/space\" or org to a Jira project key")
+	flags.StringVar(&opts.jiraIssueType, "jira-issue-type", "Task", "Jira issue type")
+
+	flags.StringVar(&opts.githubRepo, "github-repo", "", "owner/repo to open offender issues in")
+	flags.StringVar(&opts.githubToken, "github-token", os.Getenv("GITHUB_TOKEN"), "GitHub API token")
+	flags.StringVar(&opts.githubAPIURL, "github-api-url", "https://api.github.com", "GitHub API URL (for GitHub Enterprise)")
+	flags.StringVar(&opts.githubMode, "github-mode", "rollup", "open one issue per offender (per-app) or one per run (rollup)")
+
+	flags.StringVar(&opts.grafanaURL, "grafana-url", "", "post a run annotation to this Grafana instance")
+	flags.StringVar(&opts.grafanaToken, "grafana-token", os.Getenv("GRAFANA_API_KEY"), "Grafana API key or service account token")
+	flags.StringVar(&opts.grafanaDashboardUID, "grafana-dashboard", "", "limit the annotation to this dashboard UID")
+	flags.StringVar(&opts.grafanaTags, "grafana-tags", "hall-of-shame", "comma separated annotation tags")
+	flags.StringVar(&opts.telemetryEndpoint, "telemetry-endpoint", os.Getenv("HALL_OF_SHAME_TELEMETRY_URL"), "opt in to sending anonymous run statistics to this URL")
+
+	// flag stops at the first positional argument, so pick it off and keep
+	// going: "history prune --history-retain 90d" and "explain app
+	// --samples 1" both put flags after their arguments. Everything after
+	// "--" stays positional.
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		if len(rest) == 0 {
+			break
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			opts.args = append(opts.args, rest...)
+			break
+		}
+		opts.args = append(opts.args, rest[0])
+		args = rest[1:]
+	}
+
+	if opts.samples < 1 {
+		opts.samples = 1
+	}
+
+	if len(opts.nonProdSpaces) == 0 {
+		opts.nonProdSpaces = []string{"dev*", "test*", "qa*", "sandbox*"}
+	}
+
+	if opts.owners && !containsString(opts.columns, "owners") {
+		opts.columns = append(opts.columns, "owners")
+	}
+	for i, state := range opts.states {
+		opts.states[i] = strings.ToUpper(state)
+		if opts.states[i] == "STOPPED" {
+			opts.includeStopped = true
+		}
+	}
+	if (opts.includeStopped || len(opts.states) > 0) && !containsString(opts.columns, "state") {
+		opts.columns = append(opts.columns, "state")
+	}
+	if opts.processes && !containsString(opts.columns, "process") {
+		opts.columns = append(opts.columns, "process")
+	}
+	if opts.stack != "" && !containsString(opts.columns, "stack") {
+		opts.columns = append(opts.columns, "stack")
+	}
+	switch opts.sortBy {
+	case "ratio":
+	case "util":
+		if !containsString(opts.columns, "util") {
+			opts.columns = append(opts.columns, "util")
+		}
+	default:
+		return nil, fmt.Errorf("unknown --sort %q", opts.sortBy)
+	}
+	for _, name := range opts.columns {
+		if _, ok := optionalColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+
+	opts.quotaStep = recommendedStep
+	if opts.policyFile != "" {
+		if err := loadPolicyFile(opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.signKey != "" {
+		signer, err := loadSigner(opts.signKey)
+		if err != nil {
+			return nil, err
+		}
+		opts.signer = signer
+	}
+
+	if opts.failOn != "" {
+		policy, err := compilePolicy(opts.failOn)
+		if err != nil {
+			return nil, err
+		}
+		opts.failOnPolicy = policy
+	}
+
+	if opts.name != "" {
+		matcher, err := compileNameMatcher(opts.name)
+		if err != nil {
+			return nil, err
+		}
+		opts.nameMatcher = matcher
+	}
+
+	if _, ok := reportFormats[opts.output]; !ok && opts.output != "table" && opts.output != "junit" && opts.output != "gha-summary" {
+		return nil, fmt.Errorf("unknown --output %q", opts.output)
+	}
+
+	if opts.azureAccount != "" && opts.azureContainer != "" && os.Getenv("AZURE_STORAGE_KEY") == "" && os.Getenv("AZURE_STORAGE_SAS_TOKEN") == "" {
+		return nil, fmt.Errorf("Azure uploads require $AZURE_STORAGE_KEY or $AZURE_STORAGE_SAS_TOKEN")
+	}
+
+	if opts.fixture != "" && opts.replay != "" {
+		return nil, fmt.Errorf("--fixture and --replay cannot be combined")
+	}
+
+	if opts.nativeHTTP && (opts.fixture != "" || opts.replay != "" || opts.record != "") {
+		return nil, fmt.Errorf("--native-http cannot be combined with --fixture, --record or --replay")
+	}
+
+	if opts.resume && opts.checkpoint == "" {
+		return nil, fmt.Errorf("--resume requires --checkpoint")
+	}
+
+	if (opts.failOnRegression || opts.updateBaseline) && opts.baseline == "" {
+		return nil, fmt.Errorf("--fail-on-regression and --update-baseline require --baseline")
+	}
+
+	if opts.jira && (opts.jiraURL == "" || opts.jiraProject == "" && opts.jiraProjectsFile == "") {
+		return nil, fmt.Errorf("--jira requires --jira-url and --jira-project or --jira-projects")
+	}
+
+	if opts.githubRepo != "" && opts.githubMode != "rollup" && opts.githubMode != "per-app" {
+		return nil, fmt.Errorf("--github-mode must be rollup or per-app")
+	}
+
+	return opts, nil
+}
+
+type sizeFlag int
+
+func (s *sizeFlag) String() string {
+	return formatSize(int(*s))
+}
+
+func (s *sizeFlag) Set(value string) error {
+	size, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = sizeFlag(size)
+	return nil
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+type durationFlag time.Duration
+
+func (d *durationFlag) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *durationFlag) Set(value string) error {
+	duration, err := parseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = durationFlag(duration)
+	return nil
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func parseSize(value string) (int, error) {
+	units := map[string]int{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.TrimSuffix(value, "B")
+
+	multiplier := 1
+	if len(value) > 0 {
+		if unit, ok := units[value[len(value)-1:]]; ok {
+			multiplier = unit
+			value = value[:len(value)-1]
+		}
+	}
+
+	size, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	return int(size * float64(multiplier)), nil
+}
+
+func formatSize(size int) string {
+	units := []string{"B", "K", "M", "G", "T"}
+
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
+
+func envOrDefault(name string, value string) string {
+	if env := os.Getenv(name); env != "" {
+		return env
+	}
+	return value
+}
+
+func defaultStatePath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(home, ".hall-of-shame", name)
+}
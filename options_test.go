@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOptionsFlagsAfterArguments(t *testing.T) {
+
+	opts, err := parseOptions([]string{"prune", "--history-retain", "90d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opts.args, []string{"prune"}) {
+		t.Errorf("args = %q, want [prune]", opts.args)
+	}
+	if opts.historyRetain != 90*24*time.Hour {
+		t.Errorf("historyRetain = %s, want 2160h", opts.historyRetain)
+	}
+}
+
+func TestParseOptionsDoubleDash(t *testing.T) {
+
+	opts, err := parseOptions([]string{"--history-retain", "1d", "--", "prune", "--history-retain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opts.args, []string{"prune", "--history-retain"}) {
+		t.Errorf("args = %q, want [prune --history-retain]", opts.args)
+	}
+}
+
+func TestParseOptionsExplainSamples(t *testing.T) {
+
+	opts, err := parseOptions([]string{"my-app", "--samples", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opts.args, []string{"my-app"}) {
+		t.Errorf("args = %q, want [my-app]", opts.args)
+	}
+	if opts.samples != 1 {
+		t.Errorf("samples = %d, want 1", opts.samples)
+	}
+}
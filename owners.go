@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+type UserSearchResults struct {
+	NextUrl   string `json:"next_url"`
+	Resources []struct {
+		Entity struct {
+			Username string `json:"username"`
+		} `json:"entity"`
+	} `json:"resources"`
+}
+
+func (hallOfShame *HallOfShame) GetSpaceUsers(cliConnection plugin.CliConnection, spaceGuid string, role string) ([]string, error) {
+	return hallOfShame.getUsernames(cliConnection, fmt.Sprintf("/v2/spaces/%v/%v?results-per-page=100", spaceGuid, role))
+}
+
+func (hallOfShame *HallOfShame) GetOrgManagers(cliConnection plugin.CliConnection, orgGuid string) ([]string, error) {
+	return hallOfShame.getUsernames(cliConnection, fmt.Sprintf("/v2/organizations/%v/managers?results-per-page=100", orgGuid))
+}
+
+func (hallOfShame *HallOfShame) getUsernames(cliConnection plugin.CliConnection, query string) ([]string, error) {
+
+	var usernames []string
+
+	for query != "" {
+		output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+		if err != nil {
+			return nil, err
+		}
+
+		res := UserSearchResults{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			return nil, err
+		}
+
+		for _, user := range res.Resources {
+			if user.Entity.Username != "" {
+				usernames = append(usernames, user.Entity.Username)
+			}
+		}
+		query = res.NextUrl
+	}
+
+	return usernames, nil
+}
+
+func (hallOfShame *HallOfShame) resolveOwners(cliConnection plugin.CliConnection, appStats []appStatSummary, offenderRatio float64) {
+
+	owners := map[string][]string{}
+
+	for i := range appStats {
+		app := &appStats[i]
+		if app.Ratio <= offenderRatio {
+			continue
+		}
+
+		if _, ok := owners[app.Space]; !ok {
+			seen := map[string]bool{}
+			for _, role := range []string{"developers", "managers"} {
+				users, err := hallOfShame.GetSpaceUsers(cliConnection, app.Space, role)
+				if err != nil {
+					continue
+				}
+				for _, user := range users {
+					if !seen[user] {
+						seen[user] = true
+						owners[app.Space] = append(owners[app.Space], user)
+					}
+				}
+			}
+			sort.Strings(owners[app.Space])
+		}
+
+		app.Owners = owners[app.Space]
+	}
+}
+
+func ownerList(owners []string) string {
+	if len(owners) == 0 {
+		return "unknown"
+	}
+	return strings.Join(owners, ", ")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// pagedOutput buffers the report when stdout is a terminal and, like git,
+// hands it to $PAGER if it will not fit on one screen.
+type pagedOutput struct {
+	buffer bytes.Buffer
+	page   bool
+}
+
+func newPagedOutput(opts *options) *pagedOutput {
+	return &pagedOutput{page: !opts.noPager && opts.output == "table" && isTerminal(os.Stdout)}
+}
+
+func (out *pagedOutput) Write(p []byte) (int, error) {
+	if !out.page {
+		return os.Stdout.Write(p)
+	}
+	return out.buffer.Write(p)
+}
+
+func (out *pagedOutput) Flush() error {
+	if !out.page || out.buffer.Len() == 0 {
+		return nil
+	}
+	defer out.buffer.Reset()
+
+	content := out.buffer.Bytes()
+	if bytes.Count(content, []byte("\n")) < terminalHeight() {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+
+	if err := cmd.Run(); err != nil {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	return nil
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func terminalHeight() int {
+	if lines, err := strconv.Atoi(os.Getenv("LINES")); err == nil && lines > 0 {
+		return lines
+	}
+
+	tty, err := os.Open("/dev/tty")
+	if err == nil {
+		defer tty.Close()
+
+		cmd := exec.Command("stty", "size")
+		cmd.Stdin = tty
+		if output, err := cmd.Output(); err == nil {
+			fields := strings.Fields(string(output))
+			if len(fields) == 2 {
+				if rows, err := strconv.Atoi(fields[0]); err == nil && rows > 0 {
+					return rows
+				}
+			}
+		}
+	}
+
+	return 24
+}
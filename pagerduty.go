@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Component     string                 `json:"component,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+func notifyPagerDuty(opts *options, appStats []appStatSummary, unfiltered []appStatSummary) (err error) {
+
+	open := map[string]bool{}
+	if data, err := ioutil.ReadFile(opts.pagerDutyStateFile); err == nil {
+		json.Unmarshal(data, &open)
+	}
+
+	breaches := map[string]*pagerDutyPayload{}
+
+	if opts.pagerDutyWaste > 0 {
+		var totalWaste int
+		for _, app := range appStats {
+			totalWaste += app.Waste()
+		}
+
+		if totalWaste > opts.pagerDutyWaste {
+			breaches["hall-of-shame/platform-waste"] = &pagerDutyPayload{
+				Summary:  fmt.Sprintf("hall-of-shame: platform-wide memory waste of %s exceeds %s", formatSize(totalWaste), formatSize(opts.pagerDutyWaste)),
+				Source:   "hall-of-shame",
+				Severity: "warning",
+				CustomDetails: map[string]interface{}{
+					"waste":     totalWaste,
+					"threshold": opts.pagerDutyWaste,
+					"apps":      len(appStats),
+				},
+			}
+		}
+	}
+
+	if opts.pagerDutyRatio > 0 {
+		for _, app := range appStats {
+			if app.Ratio <= opts.pagerDutyRatio {
+				continue
+			}
+
+			breaches["hall-of-shame/app/"+app.Key()] = &pagerDutyPayload{
+				Summary:   fmt.Sprintf("hall-of-shame: %s allocates %.1fx the memory it uses", processAppName(app), app.Ratio),
+				Source:    "hall-of-shame",
+				Severity:  "warning",
+				Component: app.Name,
+				CustomDetails: map[string]interface{}{
+					"guid":      app.GUID,
+					"process":   app.ProcessType,
+					"space":     app.Space,
+					"alloc":     app.MemoryAlloc,
+					"avg_use":   app.AvgMemoryUse,
+					"ratio":     app.Ratio,
+					"threshold": opts.pagerDutyRatio,
+				},
+			}
+		}
+	}
+
+	if opts.orgBudgets != "" {
+		statuses, err := checkBudgets(opts, unfiltered)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			if !status.exceeded() {
+				continue
+			}
+
+			breaches["hall-of-shame/budget/"+status.Org] = &pagerDutyPayload{
+				Summary:   fmt.Sprintf("hall-of-shame: org %s is over its budget with %s allocated", status.Org, formatSize(status.Allocated)),
+				Source:    "hall-of-shame",
+				Severity:  "warning",
+				Component: status.Org,
+				CustomDetails: map[string]interface{}{
+					"allocated":     status.Allocated,
+					"memory_budget": status.MemoryBudget,
+					"monthly_cost":  status.MonthlyCost,
+					"cost_budget":   status.CostBudget,
+				},
+			}
+		}
+	}
+
+	// Persist whatever was sent even if some events failed, so incidents
+	// already triggered are resolved on a later run.
+	defer func() {
+		if writeErr := writePagerDutyState(opts.pagerDutyStateFile, open); writeErr != nil && err == nil {
+			err = writeErr
+		}
+	}()
+
+	var failed []string
+	for key, payload := range breaches {
+		if err := sendPagerDutyEvent(pagerDutyEvent{RoutingKey: opts.pagerDutyKey, EventAction: "trigger", DedupKey: key, Payload: payload}); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		open[key] = true
+	}
+
+	for key := range open {
+		if breaches[key] != nil {
+			continue
+		}
+		if err := sendPagerDutyEvent(pagerDutyEvent{RoutingKey: opts.pagerDutyKey, EventAction: "resolve", DedupKey: key}); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		delete(open, key)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d pagerduty events failed:\n  %s", len(failed), strings.Join(failed, "\n  "))
+	}
+
+	return nil
+}
+
+func writePagerDutyState(path string, open map[string]bool) error {
+
+	data, err := json.Marshal(open)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func sendPagerDutyEvent(event pagerDutyEvent) error {
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(pagerDutyEventsURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("pagerduty %s for %s failed: %s %s", event.EventAction, event.DedupKey, resp.Status, msg)
+	}
+
+	return nil
+}
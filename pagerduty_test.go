@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestNotifyPagerDutyPersistsSentEventsOnFailure(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := pagerDutyEvent{}
+		json.NewDecoder(r.Body).Decode(&event)
+		if event.DedupKey == "hall-of-shame/app/app-broken" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	defer func(url string) { pagerDutyEventsURL = url }(pagerDutyEventsURL)
+	pagerDutyEventsURL = server.URL
+
+	opts := &options{
+		pagerDutyKey:       "key",
+		pagerDutyRatio:     2,
+		pagerDutyStateFile: filepath.Join(t.TempDir(), "pagerduty.json"),
+	}
+	appStats := []appStatSummary{
+		{GUID: "app-broken", Name: "broken", Ratio: 4},
+		{GUID: "app-ok", Name: "ok", Ratio: 4},
+	}
+
+	if err := notifyPagerDuty(opts, appStats, appStats); err == nil {
+		t.Fatal("expected the failed event to be reported")
+	}
+
+	data, err := ioutil.ReadFile(opts.pagerDutyStateFile)
+	if err != nil {
+		t.Fatalf("state file was not written: %v", err)
+	}
+	open := map[string]bool{}
+	if err := json.Unmarshal(data, &open); err != nil {
+		t.Fatal(err)
+	}
+	if !open["hall-of-shame/app/app-ok"] || open["hall-of-shame/app/app-broken"] {
+		t.Errorf("expected only the sent event in the state file, got %v", open)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/danhigham/hall-of-shame/pkg/render"
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	scopeFoundation  = "foundation"
+	scopeVisibleOrgs = "visible-orgs"
+)
+
+// deniedApp is an app the user can list but whose stats answer 403.
+type deniedApp = render.DeniedApp
+
+type tokenClaims struct {
+	Scope    []string `json:"scope"`
+	UserName string   `json:"user_name"`
+	ClientID string   `json:"client_id"`
+}
+
+// accessScope is what the signed-in user can see. A token that can't be
+// read (a fixture, a replay) leaves it unknown and reports unqualified.
+type accessScope struct {
+	Known bool
+	Admin bool
+	User  string
+	Orgs  []string
+}
+
+var userAccess = accessScope{}
+
+// readTokenClaims decodes the UAA access token. The token is not verified;
+// this only decides what is worth asking the platform for and how to label
+// the report.
+func readTokenClaims(cliConnection plugin.CliConnection) (tokenClaims, bool) {
+
+	claims := tokenClaims{}
+
+	token, err := cliConnection.AccessToken()
+	if err != nil {
+		return claims, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(token, "bearer "), "Bearer "), ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, false
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+	return claims, true
+}
+
+func hasAdminScope(scopes []string) bool {
+	for _, scope := range scopes {
+		if scope == "cloud_controller.admin" || scope == "cloud_controller.admin_read_only" {
+			return true
+		}
+	}
+	return false
+}
+
+// detectAccess works out whether the report covers the whole foundation.
+// Non-admins only see the orgs they are a member of, so those are listed.
+func (hallOfShame *HallOfShame) detectAccess(cliConnection plugin.CliConnection) accessScope {
+
+	claims, ok := readTokenClaims(cliConnection)
+	if !ok {
+		return accessScope{}
+	}
+
+	access := accessScope{Known: true, Admin: hasAdminScope(claims.Scope), User: claims.UserName}
+	if access.User == "" {
+		access.User = claims.ClientID
+	}
+	if access.Admin {
+		return access
+	}
+
+	query := "/v2/organizations?results-per-page=100"
+	for query != "" {
+		output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+		if err != nil {
+			break
+		}
+
+		res := struct {
+			NextUrl   string `json:"next_url"`
+			Resources []struct {
+				Entity struct {
+					Name string `json:"name"`
+				} `json:"entity"`
+			} `json:"resources"`
+		}{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			break
+		}
+
+		for _, org := range res.Resources {
+			access.Orgs = append(access.Orgs, org.Entity.Name)
+		}
+		query = res.NextUrl
+	}
+	sort.Strings(access.Orgs)
+
+	return access
+}
+
+func (access accessScope) reportScope() string {
+	switch {
+	case !access.Known:
+		return ""
+	case access.Admin:
+		return scopeFoundation
+	default:
+		return scopeVisibleOrgs
+	}
+}
+
+// renderAccessNote says when a report is partial because the user isn't an
+// admin. Admin and unknown scopes print nothing.
+func renderAccessNote(w io.Writer, access accessScope) {
+
+	if !access.Known || access.Admin {
+		return
+	}
+
+	orgs := "no orgs"
+	switch {
+	case len(access.Orgs) == 1:
+		orgs = "1 org (" + access.Orgs[0] + ")"
+	case len(access.Orgs) > 1:
+		orgs = fmt.Sprintf("%d orgs (%s)", len(access.Orgs), strings.Join(access.Orgs, ", "))
+	}
+	fmt.Fprintf(w, "\nNote: %s is not a Cloud Controller admin; this report covers the %s they can see, not the whole foundation.\n", access.User, orgs)
+}
+
+// renderAccessDenied lists, per space, the apps left out of the report
+// because their stats could not be read, so the blind spots are visible.
+func renderAccessDenied(w io.Writer, denied []deniedApp) {
+
+	if len(denied) == 0 {
+		return
+	}
+
+	type spaceKey struct{ org, space string }
+	apps := map[spaceKey][]string{}
+	var spaces []spaceKey
+	for _, app := range denied {
+		key := spaceKey{app.Org, app.Space}
+		if _, ok := apps[key]; !ok {
+			spaces = append(spaces, key)
+		}
+		apps[key] = append(apps[key], app.Name)
+	}
+
+	fmt.Fprintf(w, "\nAccess denied: %d apps in %d spaces could not be read and are not included:\n", len(denied), len(spaces))
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Org", "Space", "Apps"})
+	for _, key := range spaces {
+		table.Append([]string{key.org, key.space, strings.Join(apps[key], ", ")})
+	}
+	table.Render()
+}
@@ -0,0 +1,144 @@
+// Package analyze holds the per-app summary the plugin reports on and the
+// scoring derived from it: utilization, waste and right-sizing.
+package analyze
+
+import "time"
+
+const (
+	// RecommendedHeadroom is the multiple of average use an app is
+	// right-sized to.
+	RecommendedHeadroom = 1.5
+	// RecommendedStep is the granularity, in bytes, of a recommended
+	// allocation.
+	RecommendedStep = 128 << 20
+)
+
+type AppSummary struct {
+	Name         string  `json:"name"`
+	GUID         string  `json:"guid"`
+	Space        string  `json:"space"`
+	SpaceName    string  `json:"space_name"`
+	Org          string  `json:"org"`
+	OrgGUID      string  `json:"org_guid"`
+	Instances    int     `json:"instances"`
+	MemoryAlloc  int     `json:"memory_alloc"`
+	AvgMemoryUse int     `json:"avg_memory_use"`
+	Ratio        float64 `json:"ratio"`
+	State        string  `json:"state"`
+
+	IsolationSegment string    `json:"isolation_segment,omitempty"`
+	Stack            string    `json:"stack,omitempty"`
+	Buildpack        string    `json:"buildpack,omitempty"`
+	Routes           []string  `json:"routes,omitempty"`
+	BadInstances     int       `json:"bad_instances,omitempty"`
+	DiskRatio        float64   `json:"disk_ratio,omitempty"`
+	DiskAlloc        int       `json:"disk_alloc,omitempty"`
+	NoUsage          bool      `json:"no_usage,omitempty"`
+	RPS              float64   `json:"rps,omitempty"`
+	Autoscaled       bool      `json:"autoscaled,omitempty"`
+	Venerable        bool      `json:"venerable,omitempty"`
+	ProcessType      string    `json:"process_type,omitempty"`
+	SidecarAlloc     int       `json:"sidecar_alloc,omitempty"`
+	Tasks            int       `json:"tasks,omitempty"`
+	TaskGBHours      float64   `json:"task_gb_hours,omitempty"`
+	Owners           []string  `json:"owners,omitempty"`
+	Team             string    `json:"team,omitempty"`
+	PushedBy         string    `json:"pushed_by,omitempty"`
+	LastCrash        string    `json:"last_crash,omitempty"`
+	QuotaVsSpace     float64   `json:"quota_vs_space,omitempty"`
+	QuotaOutlier     bool      `json:"quota_outlier,omitempty"`
+	AlignedQuota     int       `json:"aligned_quota,omitempty"`
+	AppsManagerURL   string    `json:"apps_manager_url,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type ByRatio []AppSummary
+
+type ByUtilization []AppSummary
+
+// Utilization is average use as a percentage of allocation.
+func (s *AppSummary) Utilization() float64 {
+	if s.MemoryAlloc == 0 {
+		return 0
+	}
+	return float64(s.AvgMemoryUse) / float64(s.MemoryAlloc) * 100
+}
+
+// Waste is the allocated but unused memory across all instances.
+func (s *AppSummary) Waste() int {
+	if s.AvgMemoryUse >= s.MemoryAlloc {
+		return 0
+	}
+	return (s.MemoryAlloc - s.AvgMemoryUse) * s.Instances
+}
+
+// Key identifies the row across runs: the app GUID, plus the process type
+// when --processes splits an app into one row per process.
+func (s *AppSummary) Key() string {
+	if s.ProcessType == "" {
+		return s.GUID
+	}
+	return s.GUID + "/" + s.ProcessType
+}
+
+func (s *AppSummary) LastChanged() time.Time {
+	if s.UpdatedAt.After(s.CreatedAt) {
+		return s.UpdatedAt
+	}
+	return s.CreatedAt
+}
+
+// RecommendedAlloc is the per-instance allocation the app could run with.
+func (s *AppSummary) RecommendedAlloc() int {
+	recommended := int(float64(s.AvgMemoryUse) * RecommendedHeadroom)
+	recommended = (recommended + RecommendedStep - 1) / RecommendedStep * RecommendedStep
+	if recommended < RecommendedStep {
+		recommended = RecommendedStep
+	}
+	return recommended
+}
+
+// Savings is the memory freed across all instances by moving to
+// RecommendedAlloc.
+func (s *AppSummary) Savings() int {
+	recommended := s.RecommendedAlloc()
+	if recommended >= s.MemoryAlloc {
+		return 0
+	}
+	return (s.MemoryAlloc - recommended) * s.Instances
+}
+
+func (a ByRatio) Len() int      { return len(a) }
+func (a ByRatio) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByRatio) Less(i, j int) bool {
+	if a[i].Ratio != a[j].Ratio {
+		return a[i].Ratio > a[j].Ratio
+	}
+	return tieBreak(&a[i], &a[j])
+}
+
+func (a ByUtilization) Len() int      { return len(a) }
+func (a ByUtilization) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByUtilization) Less(i, j int) bool {
+	if ui, uj := a[i].Utilization(), a[j].Utilization(); ui != uj {
+		return ui < uj
+	}
+	return tieBreak(&a[i], &a[j])
+}
+
+// tieBreak orders apps the primary key can't separate: most waste first,
+// then by name, GUID and process type, so every run and every diff lists
+// rows in the same order.
+func tieBreak(a, b *AppSummary) bool {
+	if wa, wb := a.Waste(), b.Waste(); wa != wb {
+		return wa > wb
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	if a.GUID != b.GUID {
+		return a.GUID < b.GUID
+	}
+	return a.ProcessType < b.ProcessType
+}
@@ -0,0 +1,180 @@
+// Package collector reads apps and their instance stats from the Cloud
+// Controller and turns them into analyze.AppSummary records.
+package collector
+
+import (
+	"time"
+
+	"github.com/danhigham/hall-of-shame/pkg/analyze"
+)
+
+// CloudController is the part of the Cloud Controller API collection depends
+// on, so callers can supply cf curl, plain HTTP or a fake.
+type CloudController interface {
+	GetAllApps() (AppSearchResults, error)
+	GetAppStats(appGuid string) (map[string]AppStat, error)
+}
+
+type statTime struct {
+	time.Time
+}
+
+// AllAppsPath is the first page of the V2 app list. Later pages are
+// fetched by following NextUrl until it is empty.
+const AllAppsPath = "/v2/apps?results-per-page=100"
+
+type AppSearchResults struct {
+	NextUrl   string               `json:"next_url"`
+	Resources []*AppSearchResoures `json:"resources"`
+}
+
+type AppSearchResoures struct {
+	Metadata *AppSearchMetaData `json:"metadata"`
+	Entity   *AppSearchEntity   `json:"entity"`
+}
+
+type AppSearchMetaData struct {
+	Guid      string    `json:"guid"`
+	Url       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AppSearchEntity struct {
+	Name      string `json:"name"`
+	Instances int    `json:"instances"`
+	SpaceGuid string `json:"space_guid"`
+	StackGuid string `json:"stack_guid"`
+	State     string `json:"state"`
+	Memory    int    `json:"memory"`
+	DiskQuota int    `json:"disk_quota"`
+
+	Buildpack         string `json:"buildpack"`
+	DetectedBuildpack string `json:"detected_buildpack"`
+
+	HealthCheckType         string `json:"health_check_type"`
+	HealthCheckTimeout      *int   `json:"health_check_timeout"`
+	HealthCheckHTTPEndpoint string `json:"health_check_http_endpoint"`
+}
+
+type SpaceResource struct {
+	Metadata *AppSearchMetaData `json:"metadata"`
+	Entity   struct {
+		Name             string `json:"name"`
+		OrganizationGuid string `json:"organization_guid"`
+	} `json:"entity"`
+}
+
+type OrgResource struct {
+	Metadata *AppSearchMetaData `json:"metadata"`
+	Entity   struct {
+		Name string `json:"name"`
+	} `json:"entity"`
+}
+
+type AppStat struct {
+	State        string `json:"state"`
+	IsolationSeg string `json:"isolation_segment"`
+	Stats        struct {
+		Name      string   `json:"name"`
+		Uris      []string `json:"uris"`
+		Host      string   `json:"host"`
+		Port      int      `json:"port"`
+		Uptime    int      `json:"uptime"`
+		MemQuota  int      `json:"mem_quota"`
+		DiskQuota int      `json:"disk_quota"`
+		FdsQuota  int      `json:"fds_quota"`
+		Usage     struct {
+			Time statTime `json:"time"`
+			CPU  float64  `json:"cpu"`
+			Mem  int      `json:"mem"`
+			Disk int      `json:"disk"`
+			Fds  *int     `json:"fds"`
+		} `json:"usage"`
+	} `json:"stats"`
+}
+
+// Summarize starts a summary from the app record alone; ApplyStats fills in
+// usage for apps that are running.
+func Summarize(app *AppSearchResoures) analyze.AppSummary {
+	summary := analyze.AppSummary{
+		Name:      app.Entity.Name,
+		GUID:      app.Metadata.Guid,
+		Instances: app.Entity.Instances,
+		Space:     app.Entity.SpaceGuid,
+		State:     app.Entity.State,
+		CreatedAt: app.Metadata.CreatedAt,
+		UpdatedAt: app.Metadata.UpdatedAt,
+	}
+	summary.Buildpack = app.Entity.DetectedBuildpack
+	if summary.Buildpack == "" {
+		summary.Buildpack = app.Entity.Buildpack
+	}
+	return summary
+}
+
+// ApplyStats averages memory and disk use over the app's instances.
+func ApplyStats(summary *analyze.AppSummary, stats map[string]AppStat) {
+
+	summary.State = DeriveState(stats)
+	summary.BadInstances = CountBadInstances(stats)
+
+	if len(stats) == 0 {
+		return
+	}
+
+	memAlloc := stats["0"].Stats.MemQuota
+
+	var totalUsage, totalDisk int
+	for _, stat := range stats {
+		totalUsage += stat.Stats.Usage.Mem
+		totalDisk += stat.Stats.Usage.Disk
+	}
+
+	summary.MemoryAlloc = memAlloc
+	summary.AvgMemoryUse = totalUsage / len(stats)
+	if summary.AvgMemoryUse > 0 {
+		summary.Ratio = float64(memAlloc) / float64(summary.AvgMemoryUse)
+	} else {
+		summary.NoUsage = true
+	}
+	if totalDisk > 0 {
+		summary.DiskRatio = float64(stats["0"].Stats.DiskQuota) / float64(totalDisk/len(stats))
+	}
+	summary.DiskAlloc = stats["0"].Stats.DiskQuota
+	summary.IsolationSegment = stats["0"].IsolationSeg
+	summary.Routes = stats["0"].Stats.Uris
+}
+
+func DeriveState(stats map[string]AppStat) string {
+	state := "RUNNING"
+	for _, stat := range stats {
+		switch {
+		case stat.State == "CRASHED":
+			return "CRASHED"
+		case stat.State != "RUNNING":
+			state = stat.State
+		}
+	}
+	return state
+}
+
+func CountBadInstances(stats map[string]AppStat) int {
+	var bad int
+	for _, stat := range stats {
+		switch stat.State {
+		case "CRASHED", "DOWN", "STARTING":
+			bad++
+		}
+	}
+	return bad
+}
+
+func AnyRunning(stats map[string]AppStat) bool {
+	for _, stat := range stats {
+		if stat.State == "RUNNING" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxRateLimitRetries is how many times a request answered with 429 is
+	// tried before giving up with a RateLimitError.
+	MaxRateLimitRetries = 5
+	defaultRetryAfter   = 10 * time.Second
+)
+
+type RateLimitError struct {
+	Path     string
+	Attempts int
+}
+
+func (err RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited by Cloud Controller on %s after %d attempts", err.Path, err.Attempts)
+}
+
+// ForbiddenError is a 403: the user may list the resource but not read it.
+type ForbiddenError struct {
+	Path string
+}
+
+func (err ForbiddenError) Error() string {
+	return fmt.Sprintf("access denied to %s", err.Path)
+}
+
+// RetryAfter reads Retry-After as either delay seconds or an HTTP date.
+func RetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return defaultRetryAfter
+}
+
+// HTTPClient is a CloudController that calls the V2 API directly with a UAA
+// token ("bearer ...").
+type HTTPClient struct {
+	Endpoint string
+	Token    string
+	Client   *http.Client
+	// OnResponse, if set, sees the headers of every response, e.g. to
+	// track X-RateLimit-Remaining.
+	OnResponse func(http.Header)
+}
+
+func NewHTTPClient(endpoint string, token string, skipSSLValidation bool) *HTTPClient {
+	return &HTTPClient{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Token:    token,
+		Client:   NewClient(skipSSLValidation),
+	}
+}
+
+// NewClient returns an http.Client that honours the CLI's
+// --skip-ssl-validation, for talking to the API and the components served
+// alongside it.
+func NewClient(skipSSLValidation bool) *http.Client {
+	return &http.Client{
+		Timeout:   time.Minute,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSLValidation}},
+	}
+}
+
+func (client *HTTPClient) get(path string, v interface{}) error {
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest("GET", client.Endpoint+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", client.Token)
+
+		resp, err := client.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if client.OnResponse != nil {
+			client.OnResponse(resp.Header)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if attempt == MaxRateLimitRetries {
+				return RateLimitError{Path: path, Attempts: attempt}
+			}
+			time.Sleep(RetryAfter(resp.Header.Get("Retry-After")))
+			continue
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			return ForbiddenError{Path: path}
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("GET %s failed: %s", path, resp.Status)
+		}
+
+		return json.Unmarshal(body, v)
+	}
+}
+
+func (client *HTTPClient) GetAppStats(appGuid string) (map[string]AppStat, error) {
+	statResult := map[string]AppStat{}
+	err := client.get(fmt.Sprintf("/v2/apps/%v/stats", appGuid), &statResult)
+	return statResult, err
+}
+
+func (client *HTTPClient) GetAllApps() (AppSearchResults, error) {
+
+	apps := AppSearchResults{}
+	for path := AllAppsPath; path != ""; {
+		res := AppSearchResults{}
+		if err := client.get(path, &res); err != nil {
+			return apps, err
+		}
+		apps.Resources = append(apps.Resources, res.Resources...)
+		path = res.NextUrl
+	}
+	return apps, nil
+}
@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAllAppsFollowsNextURL(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case AllAppsPath:
+			fmt.Fprint(w, `{"next_url": "/v2/apps?results-per-page=100&page=2", "resources": [{"metadata": {"guid": "a"}, "entity": {"name": "first"}}]}`)
+		case "/v2/apps?results-per-page=100&page=2":
+			fmt.Fprint(w, `{"next_url": null, "resources": [{"metadata": {"guid": "b"}, "entity": {"name": "second"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	res, err := NewHTTPClient(server.URL, "bearer test", false).GetAllApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Resources) != 2 || res.Resources[0].Entity.Name != "first" || res.Resources[1].Entity.Name != "second" {
+		t.Errorf("got %d apps, want first and second from both pages", len(res.Resources))
+	}
+}
+
+func TestGetAllAppsReturnsErrors(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"next_url": "/v2/apps?results-per-page=100&page=2", "resources": []}`)
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPClient(server.URL, "bearer test", false).GetAllApps(); err == nil {
+		t.Error("expected the failed second page to be an error, not a truncated list")
+	}
+}
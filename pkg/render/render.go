@@ -0,0 +1,109 @@
+// Package render writes app summaries in the machine-readable report
+// formats. Table output stays with the plugin, which owns its options.
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danhigham/hall-of-shame/pkg/analyze"
+)
+
+// Metadata records what produced a report so a run can be reproduced.
+type Metadata struct {
+	SchemaVersion int       `json:"schema_version"`
+	PluginVersion string    `json:"plugin_version"`
+	GitCommit     string    `json:"git_commit"`
+	BuildDate     string    `json:"build_date"`
+	GoVersion     string    `json:"go_version"`
+	CAPIVersion   string    `json:"capi_version,omitempty"`
+	CLIVersion    string    `json:"cli_version,omitempty"`
+	Foundation    string    `json:"foundation,omitempty"`
+	GeneratedAt   time.Time `json:"generated_at"`
+
+	// Scope is "foundation" when an admin produced the report and
+	// "visible-orgs" when it only covers the user's orgs.
+	Scope string `json:"scope,omitempty"`
+	// AccessDenied lists apps that were listed but whose stats answered
+	// 403, and so are missing from the report.
+	AccessDenied []DeniedApp `json:"access_denied,omitempty"`
+}
+
+type DeniedApp struct {
+	Name  string `json:"name"`
+	GUID  string `json:"guid"`
+	Space string `json:"space"`
+	Org   string `json:"org"`
+}
+
+type jsonReport struct {
+	Metadata Metadata             `json:"metadata"`
+	Apps     []analyze.AppSummary `json:"apps"`
+}
+
+func JSON(w io.Writer, metadata Metadata, appStats []analyze.AppSummary) error {
+	metadata.SchemaVersion = SchemaVersion
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonReport{Metadata: metadata, Apps: appStats})
+}
+
+// CSV writes one row per app, with ratios to the given number of decimals.
+func CSV(w io.Writer, appStats []analyze.AppSummary, decimals int) error {
+	writer := csv.NewWriter(w)
+	writer.Write(CSVHeader)
+
+	for _, v := range appStats {
+		writer.Write([]string{
+			v.Name,
+			v.GUID,
+			v.Space,
+			v.SpaceName,
+			v.Org,
+			fmt.Sprintf("%d", v.Instances),
+			fmt.Sprintf("%d", v.MemoryAlloc),
+			fmt.Sprintf("%d", v.AvgMemoryUse),
+			strconv.FormatFloat(v.Ratio, 'f', decimals, 64),
+			v.State,
+			fmt.Sprintf("%d", v.BadInstances),
+			strconv.FormatFloat(v.DiskRatio, 'f', decimals, 64),
+			fmt.Sprintf("%t", v.NoUsage),
+			fmt.Sprintf("%f", v.RPS),
+			fmt.Sprintf("%t", v.Autoscaled),
+			fmt.Sprintf("%t", v.Venerable),
+			v.ProcessType,
+			fmt.Sprintf("%d", v.Tasks),
+			fmt.Sprintf("%f", v.TaskGBHours),
+			strings.Join(v.Owners, ";"),
+			v.Team,
+			v.PushedBy,
+			v.Stack,
+			v.Buildpack,
+			strings.Join(v.Routes, ";"),
+			v.AppsManagerURL,
+			Timestamp(v.CreatedAt),
+			Timestamp(v.UpdatedAt),
+			v.LastCrash,
+			strconv.FormatFloat(v.QuotaVsSpace, 'f', decimals, 64),
+			fmt.Sprintf("%t", v.QuotaOutlier),
+			fmt.Sprintf("%d", v.AlignedQuota),
+			fmt.Sprintf("%d", v.SidecarAlloc),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Timestamp formats t as RFC 3339 in UTC, or "" when unset.
+func Timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danhigham/hall-of-shame/pkg/analyze"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+// testdata/apps.json is what the plugin collects from its testdata/run.json
+// fixture; the plugin's own tests keep the two in step.
+func loadApps(t *testing.T) []analyze.AppSummary {
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "apps.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var appStats []analyze.AppSummary
+	if err := json.Unmarshal(data, &appStats); err != nil {
+		t.Fatal(err)
+	}
+	return appStats
+}
+
+func checkGolden(t *testing.T, name string, output []byte) {
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, output, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, want) {
+		t.Errorf("%s differs from the output (go test -update rewrites it):\n%s", path, output)
+	}
+}
+
+func TestJSONGolden(t *testing.T) {
+
+	metadata := Metadata{
+		PluginVersion: "1.2.3",
+		GitCommit:     "0123abc",
+		BuildDate:     "2025-01-01T00:00:00Z",
+		GoVersion:     "go1.21",
+		CAPIVersion:   "2.186.0",
+		Foundation:    "api.demo.example.com",
+		GeneratedAt:   time.Date(2025, 1, 20, 6, 0, 0, 0, time.UTC),
+		Scope:         "foundation",
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := JSON(buffer, metadata, loadApps(t)); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report.json.golden", buffer.Bytes())
+}
+
+func TestCSVGolden(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+	if err := CSV(buffer, loadApps(t), 2); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report.csv.golden", buffer.Bytes())
+}
+
+func TestCSVHeaderMatchesRows(t *testing.T) {
+
+	buffer := &bytes.Buffer{}
+	if err := CSV(buffer, []analyze.AppSummary{{Name: "app"}}, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want header and one row", len(lines))
+	}
+	if header, row := bytes.Count(lines[0], []byte(",")), bytes.Count(lines[1], []byte(",")); header != row {
+		t.Errorf("header has %d columns, row has %d", header+1, row+1)
+	}
+}
@@ -0,0 +1,27 @@
+package render
+
+// SchemaVersion identifies the JSON and CSV report layout and is written to
+// every JSON report as metadata.schema_version.
+//
+// Within a schema version fields are only ever added: JSON keys and CSV
+// columns keep their names, types and meaning, and new CSV columns are
+// appended after the existing ones, so scripts that read columns by name or
+// by position keep working. Renaming, removing or reordering a field, or
+// changing its unit, bumps SchemaVersion and is called out in the release
+// notes. The golden files in testdata pin both layouts; regenerate them with
+// go test -update only for an intended change.
+//
+// Version 1:
+//   - JSON: {"metadata": {...}, "apps": [...]}; each app carries the fields
+//     of analyze.AppSummary under their json tags. Memory figures are bytes.
+//   - CSV: the columns in CSVHeader, memory in bytes, ratios to --decimals
+//     places, lists joined with ";", timestamps RFC 3339 in UTC.
+const SchemaVersion = 1
+
+// CSVHeader is the CSV column contract for SchemaVersion.
+var CSVHeader = []string{
+	"name", "guid", "space", "space_name", "org", "instances", "memory_alloc", "avg_memory_use", "ratio", "state",
+	"bad_instances", "disk_ratio", "no_usage", "rps", "autoscaled", "venerable", "process_type", "tasks", "task_gb_hours",
+	"owners", "team", "pushed_by", "stack", "buildpack", "routes", "apps_manager_url", "created_at", "updated_at",
+	"last_crash", "quota_vs_space", "quota_outlier", "aligned_quota", "sidecar_alloc",
+}
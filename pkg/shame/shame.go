@@ -0,0 +1,145 @@
+// Package shame is the embeddable entry point to hall-of-shame: collect app
+// memory usage from a Cloud Controller and score it, without the cf CLI.
+//
+//	apps, err := shame.Collect(ctx, client, shame.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	for _, result := range shame.Score(apps, 2) {
+//		if result.Offender {
+//			notify(result.Name, result.Savings)
+//		}
+//	}
+package shame
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/danhigham/hall-of-shame/pkg/analyze"
+	"github.com/danhigham/hall-of-shame/pkg/collector"
+)
+
+type (
+	// AppSummary is one app's allocation and average use.
+	AppSummary = analyze.AppSummary
+	// Client fetches apps and instance stats from a Cloud Controller.
+	Client = collector.CloudController
+)
+
+type Options struct {
+	// IncludeStopped reports stopped apps, with their allocation and no usage.
+	IncludeStopped bool
+	// IncludeUnhealthy keeps apps with no RUNNING instance (crashed, down).
+	IncludeUnhealthy bool
+	// Concurrency is the number of apps whose stats are fetched at once;
+	// the default is 2, as in the plugin.
+	Concurrency int
+}
+
+// Collect summarizes every app the client can see, highest ratio first. Org
+// and space names are left empty; Space holds the space GUID. A failure
+// fetching one app's stats drops that app rather than the whole collection.
+func Collect(ctx context.Context, client Client, opts Options) ([]AppSummary, error) {
+
+	res, err := client.GetAllApps()
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 2
+	}
+
+	var (
+		apps  []AppSummary
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	slots := make(chan struct{}, concurrency)
+
+	for _, app := range res.Resources {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(app *collector.AppSearchResoures) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			summary, ok := collectApp(client, app, opts)
+			if !ok {
+				return
+			}
+			mutex.Lock()
+			apps = append(apps, summary)
+			mutex.Unlock()
+		}(app)
+	}
+	wg.Wait()
+
+	sort.Stable(analyze.ByRatio(apps))
+	return apps, nil
+}
+
+func collectApp(client Client, app *collector.AppSearchResoures, opts Options) (AppSummary, bool) {
+
+	summary := collector.Summarize(app)
+
+	if app.Entity.State == "STOPPED" {
+		summary.MemoryAlloc = app.Entity.Memory << 20
+		return summary, opts.IncludeStopped
+	}
+
+	stats, err := client.GetAppStats(app.Metadata.Guid)
+	if err != nil || len(stats) == 0 {
+		return summary, false
+	}
+
+	collector.ApplyStats(&summary, stats)
+	return summary, opts.IncludeUnhealthy || collector.AnyRunning(stats)
+}
+
+// Result is an app with the figures hall-of-shame ranks it by. Memory
+// figures are bytes.
+type Result struct {
+	AppSummary
+	Utilization      float64 `json:"utilization"`
+	Wasted           int     `json:"waste"`
+	RecommendedAlloc int     `json:"recommended_alloc"`
+	Savings          int     `json:"savings"`
+	// Offender is set when the allocation/use ratio exceeds the threshold
+	// passed to Score.
+	Offender bool `json:"offender"`
+}
+
+// Score ranks apps by allocation/use ratio, highest first, and flags those
+// above offenderRatio (the plugin's default is 2).
+func Score(apps []AppSummary, offenderRatio float64) []Result {
+
+	sorted := append([]AppSummary(nil), apps...)
+	sort.Stable(analyze.ByRatio(sorted))
+
+	results := make([]Result, 0, len(sorted))
+	for i := range sorted {
+		app := &sorted[i]
+		results = append(results, Result{
+			AppSummary:       *app,
+			Utilization:      app.Utilization(),
+			Wasted:           app.Waste(),
+			RecommendedAlloc: app.RecommendedAlloc(),
+			Savings:          app.Savings(),
+			Offender:         app.Ratio > offenderRatio,
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+const (
+	policyScaleOutUtil = 80
+	policyScaleInUtil  = 30
+)
+
+type autoscalerPolicy struct {
+	InstanceMinCount int              `json:"instance_min_count"`
+	InstanceMaxCount int              `json:"instance_max_count"`
+	ScalingRules     []autoscalerRule `json:"scaling_rules"`
+}
+
+type autoscalerRule struct {
+	MetricType         string `json:"metric_type"`
+	BreachDurationSecs int    `json:"breach_duration_secs"`
+	Threshold          int    `json:"threshold"`
+	Operator           string `json:"operator"`
+	CoolDownSecs       int    `json:"cool_down_secs"`
+	Adjustment         string `json:"adjustment"`
+}
+
+// suggestPolicy keeps the current instance count as the ceiling and sizes
+// the floor so that observed usage still fits with the usual headroom.
+func suggestPolicy(app *appStatSummary) autoscalerPolicy {
+
+	used := float64(app.AvgMemoryUse*app.Instances) * recommendedHeadroom
+	min := int(used/float64(app.MemoryAlloc)) + 1
+	if min > app.Instances {
+		min = app.Instances
+	}
+
+	return autoscalerPolicy{
+		InstanceMinCount: min,
+		InstanceMaxCount: app.Instances,
+		ScalingRules: []autoscalerRule{
+			{"memoryutil", 600, policyScaleOutUtil, ">=", 300, "+1"},
+			{"memoryutil", 600, policyScaleInUtil, "<", 300, "-1"},
+		},
+	}
+}
+
+func policyCandidate(opts *options, app *appStatSummary) bool {
+	return !app.Autoscaled && !app.NoUsage && app.State != "STOPPED" &&
+		app.Instances > 1 && app.Ratio > opts.offenderRatio
+}
+
+func (hallOfShame *HallOfShame) runPoliciesCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	appStats, err := hallOfShame.collect(cliConnection, opts)
+	if err != nil {
+		return err
+	}
+	appStats = applyFilters(opts, appStats)
+
+	if !opts.excludeAutoscaled {
+		if err := hallOfShame.markAutoscaled(cliConnection, appStats); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(opts.policyDir, 0755); err != nil {
+		return err
+	}
+
+	var written int
+	for i := range appStats {
+		app := &appStats[i]
+		if !policyCandidate(opts, app) {
+			continue
+		}
+
+		data, err := json.MarshalIndent(suggestPolicy(app), "", "  ")
+		if err != nil {
+			return err
+		}
+
+		name := teamFileUnsafe.ReplaceAllString(fmt.Sprintf("%s_%s_%s", app.Org, app.SpaceName, app.Name), "_")
+		if err := ioutil.WriteFile(filepath.Join(opts.policyDir, name+".json"), data, 0644); err != nil {
+			return err
+		}
+		written++
+	}
+
+	fmt.Printf("Wrote %d autoscaler policies to %s\n", written, opts.policyDir)
+	return nil
+}
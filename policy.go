@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type appPolicy func(app *appStatSummary) bool
+
+var policyNumberFields = map[string]func(*appStatSummary) float64{
+	"ratio":         func(s *appStatSummary) float64 { return s.Ratio },
+	"alloc":         func(s *appStatSummary) float64 { return float64(s.MemoryAlloc) },
+	"use":           func(s *appStatSummary) float64 { return float64(s.AvgMemoryUse) },
+	"waste":         func(s *appStatSummary) float64 { return float64(s.Waste()) },
+	"savings":       func(s *appStatSummary) float64 { return float64(s.Savings()) },
+	"instances":     func(s *appStatSummary) float64 { return float64(s.Instances) },
+	"util":          func(s *appStatSummary) float64 { return s.Utilization() },
+	"disk_ratio":    func(s *appStatSummary) float64 { return s.DiskRatio },
+	"bad_instances": func(s *appStatSummary) float64 { return float64(s.BadInstances) },
+	"rps":           func(s *appStatSummary) float64 { return s.RPS },
+	"tasks":         func(s *appStatSummary) float64 { return float64(s.Tasks) },
+	"aligned_quota": func(s *appStatSummary) float64 { return float64(s.AlignedQuota) },
+	"age_days": func(s *appStatSummary) float64 {
+		return time.Since(s.LastChanged()).Hours() / 24
+	},
+}
+
+var policyStringFields = map[string]func(*appStatSummary) string{
+	"name":  func(s *appStatSummary) string { return s.Name },
+	"space": func(s *appStatSummary) string { return s.SpaceName },
+	"org":   func(s *appStatSummary) string { return s.Org },
+	"state": func(s *appStatSummary) string { return s.State },
+	"stack": func(s *appStatSummary) string { return s.Stack },
+	"team":  func(s *appStatSummary) string { return s.Team },
+}
+
+type policyParser struct {
+	tokens []string
+	pos    int
+}
+
+// compilePolicy parses expressions such as `ratio>4 && waste>2G` or
+// `org=="sandbox" || (util<10 && instances>=2)`. Numbers accept the same
+// size suffixes as --min-alloc.
+func compilePolicy(expression string) (appPolicy, error) {
+
+	tokens, err := tokenizePolicy(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &policyParser{tokens: tokens}
+	policy, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected %q in --fail-on", parser.tokens[parser.pos])
+	}
+
+	return policy, nil
+}
+
+func tokenizePolicy(expression string) ([]string, error) {
+
+	var tokens []string
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.HasPrefix(expression[i:], "&&"), strings.HasPrefix(expression[i:], "||"),
+			strings.HasPrefix(expression[i:], ">="), strings.HasPrefix(expression[i:], "<="),
+			strings.HasPrefix(expression[i:], "=="), strings.HasPrefix(expression[i:], "!="):
+			tokens = append(tokens, expression[i:i+2])
+			i += 2
+		case strings.ContainsRune("()<>!", rune(c)):
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in --fail-on")
+			}
+			tokens = append(tokens, expression[i:i+end+2])
+			i += end + 2
+		default:
+			start := i
+			for i < len(expression) && !strings.ContainsRune(" \t&|()<>=!\"'", rune(expression[i])) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected %q in --fail-on", string(c))
+			}
+			tokens = append(tokens, expression[start:i])
+		}
+	}
+
+	return tokens, nil
+}
+
+func (parser *policyParser) peek() string {
+	if parser.pos < len(parser.tokens) {
+		return parser.tokens[parser.pos]
+	}
+	return ""
+}
+
+func (parser *policyParser) next() string {
+	token := parser.peek()
+	parser.pos++
+	return token
+}
+
+func (parser *policyParser) or() (appPolicy, error) {
+
+	left, err := parser.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.peek() == "||" {
+		parser.next()
+		right, err := parser.and()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(app *appStatSummary) bool { return l(app) || r(app) }
+	}
+
+	return left, nil
+}
+
+func (parser *policyParser) and() (appPolicy, error) {
+
+	left, err := parser.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.peek() == "&&" {
+		parser.next()
+		right, err := parser.unary()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(app *appStatSummary) bool { return l(app) && r(app) }
+	}
+
+	return left, nil
+}
+
+func (parser *policyParser) unary() (appPolicy, error) {
+
+	switch parser.peek() {
+	case "!":
+		parser.next()
+		inner, err := parser.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(app *appStatSummary) bool { return !inner(app) }, nil
+	case "(":
+		parser.next()
+		inner, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+		if parser.next() != ")" {
+			return nil, fmt.Errorf("missing ) in --fail-on")
+		}
+		return inner, nil
+	}
+
+	return parser.comparison()
+}
+
+func (parser *policyParser) comparison() (appPolicy, error) {
+
+	field, op, value := parser.next(), parser.next(), parser.next()
+	if value == "" {
+		return nil, fmt.Errorf("incomplete comparison %q in --fail-on", strings.TrimSpace(field+" "+op))
+	}
+
+	if getter, ok := policyStringFields[field]; ok {
+		value = strings.Trim(value, `"'`)
+		switch op {
+		case "==":
+			return func(app *appStatSummary) bool { return getter(app) == value }, nil
+		case "!=":
+			return func(app *appStatSummary) bool { return getter(app) != value }, nil
+		}
+		return nil, fmt.Errorf("%s only supports == and != in --fail-on", field)
+	}
+
+	getter, ok := policyNumberFields[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q in --fail-on", field)
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		size, err := parseSize(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s in --fail-on", value, field)
+		}
+		number = float64(size)
+	}
+
+	switch op {
+	case ">":
+		return func(app *appStatSummary) bool { return getter(app) > number }, nil
+	case ">=":
+		return func(app *appStatSummary) bool { return getter(app) >= number }, nil
+	case "<":
+		return func(app *appStatSummary) bool { return getter(app) < number }, nil
+	case "<=":
+		return func(app *appStatSummary) bool { return getter(app) <= number }, nil
+	case "==":
+		return func(app *appStatSummary) bool { return getter(app) == number }, nil
+	case "!=":
+		return func(app *appStatSummary) bool { return getter(app) != number }, nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %q in --fail-on", op)
+}
+
+func policyViolations(policy appPolicy, appStats []appStatSummary) []appStatSummary {
+	var violations []appStatSummary
+	for i := range appStats {
+		if policy(&appStats[i]) {
+			violations = append(violations, appStats[i])
+		}
+	}
+	return violations
+}
+
+func renderViolations(w io.Writer, expression string, violations []appStatSummary) {
+	fmt.Fprintf(w, "\n%d apps match --fail-on %q:\n", len(violations), expression)
+	for _, app := range violations {
+		fmt.Fprintf(w, "  %s (%s/%s) ratio %.2f, waste %s\n", app.Name, app.Org, app.SpaceName, app.Ratio, formatSize(app.Waste()))
+	}
+}
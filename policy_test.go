@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompilePolicy(t *testing.T) {
+
+	// 4G allocated, 512M used: ratio 8, 3.5G wasted.
+	app := appStatSummary{Name: "api", Org: "acme", SpaceName: "prod", State: "RUNNING", Instances: 1, MemoryAlloc: 4 << 30, AvgMemoryUse: 512 << 20, Ratio: 8}
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{`ratio>4`, true},
+		{`ratio>=8 && ratio<=8`, true},
+		{`ratio!=8`, false},
+		{`waste>2G`, true},
+		{`waste>4G`, false},
+		{`alloc==4096M`, true},
+		{`org=="acme"`, true},
+		{`org=='sandbox'`, false},
+		{`space!="prod"`, false},
+		{`!ratio>4`, false},
+		{`!(org=="sandbox")`, true},
+		{`ratio<2 && org=="acme" || instances==1`, true},
+		{`instances==1 || ratio<2 && org=="sandbox"`, true},
+		{`(instances==1 || ratio<2) && org=="sandbox"`, false},
+		{`org=="sandbox" || (ratio>4 && waste>2G)`, true},
+		{`!(ratio>4 && org=="acme") || state=="STOPPED"`, false},
+	}
+
+	for _, test := range tests {
+		policy, err := compilePolicy(test.expression)
+		if err != nil {
+			t.Errorf("%s: %v", test.expression, err)
+			continue
+		}
+		if got := policy(&app); got != test.want {
+			t.Errorf("%s = %t, want %t", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestCompilePolicyErrors(t *testing.T) {
+
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{`colour=="red"`, `unknown field "colour"`},
+		{`(ratio>4 && waste>2G`, "missing )"},
+		{`ratio>4 &&`, "incomplete comparison"},
+		{`|| ratio>4`, `unknown field "||"`},
+		{`ratio>`, "incomplete comparison"},
+		{`ratio>4 waste>2G`, `unexpected "waste"`},
+		{`ratio~4`, `incomplete comparison "ratio~4"`},
+		{`ratio=>4`, `unexpected "="`},
+		{`ratio<>4`, `invalid value ">" for ratio`},
+		{`ratio(4`, `unknown operator "("`},
+		{`org>"acme"`, "org only supports == and !="},
+		{`waste>lots`, `invalid value "lots" for waste`},
+		{`name=="api`, "unterminated string"},
+	}
+
+	for _, test := range tests {
+		_, err := compilePolicy(test.expression)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.expression)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %q, want %q", test.expression, err, test.err)
+		}
+	}
+}
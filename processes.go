@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+type V3ProcessResults struct {
+	Resources []struct {
+		Guid       string `json:"guid"`
+		Type       string `json:"type"`
+		Instances  int    `json:"instances"`
+		MemoryInMb int    `json:"memory_in_mb"`
+	} `json:"resources"`
+}
+
+type V3SidecarResults struct {
+	Resources []struct {
+		Name         string   `json:"name"`
+		ProcessTypes []string `json:"process_types"`
+		MemoryInMb   int      `json:"memory_in_mb"`
+	} `json:"resources"`
+}
+
+type V3ProcessStats struct {
+	Resources []struct {
+		State string `json:"state"`
+		Usage struct {
+			Mem  int `json:"mem"`
+			Disk int `json:"disk"`
+		} `json:"usage"`
+		MemQuota  int `json:"mem_quota"`
+		DiskQuota int `json:"disk_quota"`
+	} `json:"resources"`
+}
+
+func (hallOfShame *HallOfShame) v3Get(cliConnection plugin.CliConnection, path string, v interface{}) error {
+
+	output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(strings.Join(output, "")), v)
+}
+
+// processBreakdown splits an app into one row per process type, plus one
+// per sidecar. Sidecar memory is carved out of its process's quota and the
+// stats endpoint does not report it separately, so sidecar rows only note
+// their share in SidecarAlloc. The process row already allocates it, so
+// sidecar rows add nothing to totals, Waste or Savings.
+func (hallOfShame *HallOfShame) processBreakdown(cliConnection plugin.CliConnection, app appStatSummary) ([]appStatSummary, error) {
+
+	processes := V3ProcessResults{}
+	if err := hallOfShame.v3Get(cliConnection, fmt.Sprintf("/v3/apps/%v/processes", app.GUID), &processes); err != nil {
+		return nil, err
+	}
+
+	var rows []appStatSummary
+	instances := map[string]int{}
+
+	for _, process := range processes.Resources {
+		instances[process.Type] = process.Instances
+		if process.Instances == 0 {
+			continue
+		}
+
+		stats := V3ProcessStats{}
+		if err := hallOfShame.v3Get(cliConnection, fmt.Sprintf("/v3/processes/%v/stats", process.Guid), &stats); err != nil {
+			return nil, err
+		}
+
+		row := app
+		row.ProcessType = process.Type
+		row.Instances = process.Instances
+		row.MemoryAlloc = process.MemoryInMb << 20
+		row.AvgMemoryUse, row.Ratio, row.DiskRatio, row.NoUsage = 0, 0, 0, false
+
+		var running, totalUsage, totalDisk, diskQuota int
+		for _, instance := range stats.Resources {
+			if instance.State != "RUNNING" {
+				continue
+			}
+			running++
+			totalUsage += instance.Usage.Mem
+			totalDisk += instance.Usage.Disk
+			diskQuota = instance.DiskQuota
+		}
+
+		if running > 0 {
+			row.AvgMemoryUse = totalUsage / running
+		}
+		if row.AvgMemoryUse > 0 {
+			row.Ratio = float64(row.MemoryAlloc) / float64(row.AvgMemoryUse)
+		} else {
+			row.NoUsage = true
+		}
+		if totalDisk > 0 {
+			row.DiskRatio = float64(diskQuota) / float64(totalDisk/running)
+		}
+
+		rows = append(rows, row)
+	}
+
+	sidecars := V3SidecarResults{}
+	if err := hallOfShame.v3Get(cliConnection, fmt.Sprintf("/v3/apps/%v/sidecars", app.GUID), &sidecars); err != nil {
+		return nil, err
+	}
+
+	for _, sidecar := range sidecars.Resources {
+		row := app
+		row.ProcessType = "sidecar:" + sidecar.Name
+		row.Instances = 0
+		for _, processType := range sidecar.ProcessTypes {
+			row.Instances += instances[processType]
+		}
+		row.MemoryAlloc, row.SidecarAlloc = 0, sidecar.MemoryInMb<<20
+		row.AvgMemoryUse, row.Ratio, row.DiskRatio, row.NoUsage = 0, 0, 0, true
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// processAppName names a row in alerts and issues, adding the process type
+// so --processes rows of one app stay distinguishable.
+func processAppName(app appStatSummary) string {
+	if app.ProcessType == "" {
+		return app.Name
+	}
+	return fmt.Sprintf("%s (%s)", app.Name, app.ProcessType)
+}
@@ -0,0 +1,52 @@
+package main
+
+import "sort"
+
+// minQuotaPeers is how many apps a space needs before its median quota says
+// anything about how the space sizes apps.
+const minQuotaPeers = 3
+
+// markQuotaOutliers compares each app's quota with the median in its space
+// and flags those more than factor times larger, the usual sign of a
+// manifest copied from a much bigger app.
+func markQuotaOutliers(appStats []appStatSummary, factor float64) {
+
+	quotas := map[string][]int{}
+	for _, app := range appStats {
+		if app.MemoryAlloc > 0 {
+			quotas[app.Space] = append(quotas[app.Space], app.MemoryAlloc)
+		}
+	}
+
+	medians := map[string]int{}
+	for space, sizes := range quotas {
+		if len(sizes) < minQuotaPeers {
+			continue
+		}
+		sort.Ints(sizes)
+		median := sizes[len(sizes)/2]
+		if len(sizes)%2 == 0 {
+			median = (sizes[len(sizes)/2-1] + median) / 2
+		}
+		medians[space] = median
+	}
+
+	for i := range appStats {
+		app := &appStats[i]
+		if median := medians[app.Space]; median > 0 && app.MemoryAlloc > 0 {
+			app.QuotaVsSpace = float64(app.MemoryAlloc) / float64(median)
+			app.QuotaOutlier = app.QuotaVsSpace > factor
+		}
+	}
+}
+
+func quotaVsSpace(s *appStatSummary) string {
+	if s.QuotaVsSpace == 0 {
+		return ""
+	}
+	value := tableNumbers.float(s.QuotaVsSpace) + "x"
+	if s.QuotaOutlier {
+		value += " HIGH"
+	}
+	return value
+}
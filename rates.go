@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+// hoursPerMonth is the average month (365 x 24 / 12 hours) that showback,
+// budgets and per-app cost all price against.
+const hoursPerMonth = 730
+
+// costRate prices a foundation or isolation segment. Empty scopes, or "*",
+// match anything.
+type costRate struct {
+	Foundation       string  `json:"-"`
+	IsolationSegment string  `json:"-"`
+	Currency         string  `json:"currency"`
+	MemoryGBHour     float64 `json:"memory_gb_hour"`
+	DiskGBMonth      float64 `json:"disk_gb_month"`
+	InstanceMonth    float64 `json:"instance_month"`
+}
+
+type rateTable struct {
+	foundation string
+	rates      []costRate
+}
+
+// costRates is what showback and budgets price with: the --rates file, or a
+// single memory-only rate from --rate-gb-hour and --currency.
+var costRates = &rateTable{}
+
+var ratesSchema = &configSchema{kind: "object", values: &configSchema{kind: "object", fields: map[string]*configSchema{
+	"currency":       stringSchema,
+	"memory_gb_hour": numberSchema,
+	"disk_gb_month":  numberSchema,
+	"instance_month": numberSchema,
+}}}
+
+func configureRates(cliConnection plugin.CliConnection, opts *options) error {
+
+	costRates = &rateTable{}
+	if api, err := cliConnection.ApiEndpoint(); err == nil {
+		if u, err := url.Parse(api); err == nil {
+			costRates.foundation = u.Host
+		}
+	}
+
+	if opts.rates == "" {
+		if opts.rateGBHour > 0 {
+			costRates.rates = []costRate{{Currency: opts.currency, MemoryGBHour: opts.rateGBHour}}
+		}
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(opts.rates)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(opts.rates), ".csv") {
+		costRates.rates, err = parseRatesCSV(opts.rates, data)
+	} else {
+		costRates.rates, err = parseRatesJSON(opts.rates, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range costRates.rates {
+		if costRates.rates[i].Currency == "" {
+			costRates.rates[i].Currency = opts.currency
+		}
+	}
+	return nil
+}
+
+// parseRatesCSV reads rows of
+// foundation,isolation_segment,currency,memory_gb_hour,disk_gb_month,instance_month
+// with a header line.
+func parseRatesCSV(name string, data []byte) ([]costRate, error) {
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s: no rates", name)
+	}
+
+	columns := map[string]int{}
+	for i, column := range records[0] {
+		columns[strings.TrimSpace(column)] = i
+	}
+	for _, column := range []string{"foundation", "isolation_segment", "memory_gb_hour"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("%s: missing column %q", name, column)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(line int, record []string, column string) (float64, error) {
+		value := field(record, column)
+		if value == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s:%d: %s: %v", name, line, column, err)
+		}
+		return n, nil
+	}
+
+	var rates []costRate
+	for i, record := range records[1:] {
+		line := i + 2
+		rate := costRate{
+			Foundation:       field(record, "foundation"),
+			IsolationSegment: field(record, "isolation_segment"),
+			Currency:         field(record, "currency"),
+		}
+		if rate.MemoryGBHour, err = number(line, record, "memory_gb_hour"); err != nil {
+			return nil, err
+		}
+		if rate.DiskGBMonth, err = number(line, record, "disk_gb_month"); err != nil {
+			return nil, err
+		}
+		if rate.InstanceMonth, err = number(line, record, "instance_month"); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// parseRatesJSON reads an object keyed by "<foundation>/<isolation segment>",
+// e.g. "*/*" for the default or "api.sys.example.com/gpu".
+func parseRatesJSON(name string, data []byte) ([]costRate, error) {
+
+	if err := validateConfig(name, data, ratesSchema); err != nil {
+		return nil, err
+	}
+
+	scoped := map[string]costRate{}
+	if err := json.Unmarshal(data, &scoped); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", name, err)
+	}
+
+	var rates []costRate
+	for scope, rate := range scoped {
+		parts := strings.SplitN(scope, "/", 2)
+		rate.Foundation = parts[0]
+		if len(parts) == 2 {
+			rate.IsolationSegment = parts[1]
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func (table *rateTable) configured() bool {
+	return len(table.rates) > 0
+}
+
+// forSegment picks the most specific rate for an isolation segment on the
+// current foundation; a segment match outranks a foundation match.
+func (table *rateTable) forSegment(segment string) costRate {
+
+	if segment == "" {
+		segment = sharedSegment
+	}
+
+	best, bestScore := costRate{}, -1
+	for _, rate := range table.rates {
+		score := 0
+		switch rate.Foundation {
+		case "", "*":
+		case table.foundation:
+			score++
+		default:
+			continue
+		}
+		switch rate.IsolationSegment {
+		case "", "*":
+		case segment:
+			score += 2
+		default:
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = rate, score
+		}
+	}
+	return best
+}
+
+func (table *rateTable) currency(fallback string) string {
+	if currency := table.forSegment("").Currency; currency != "" {
+		return currency
+	}
+	return fallback
+}
+
+// monthlyCost prices an app's current footprint for a month.
+func (rate costRate) monthlyCost(app *appStatSummary) float64 {
+	instances := float64(app.Instances)
+	memory := float64(app.MemoryAlloc) / (1 << 30) * instances * hoursPerMonth * rate.MemoryGBHour
+	disk := float64(app.DiskAlloc) / (1 << 30) * instances * rate.DiskGBMonth
+	return memory + disk + instances*rate.InstanceMonth
+}
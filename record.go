@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+// A recording is a directory holding meta.json, one file per response and
+// requests.jsonl mapping each cf curl path to its file. Later lines win, so a
+// path fetched twice replays its most recent response.
+type recordedRequest struct {
+	Path string `json:"path"`
+	File string `json:"file"`
+}
+
+type recordingConnection struct {
+	plugin.CliConnection
+
+	mutex sync.Mutex
+	dir   string
+	index *os.File
+	count int
+}
+
+func newRecordingConnection(cliConnection plugin.CliConnection, dir string) (*recordingConnection, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	meta := fixtureFile{}
+	meta.ApiEndpoint, _ = cliConnection.ApiEndpoint()
+	meta.ApiVersion, _ = cliConnection.ApiVersion()
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "meta.json"), data, 0644); err != nil {
+		return nil, err
+	}
+
+	index, err := os.OpenFile(filepath.Join(dir, "requests.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingConnection{CliConnection: cliConnection, dir: dir, index: index}, nil
+}
+
+func (connection *recordingConnection) CliCommandWithoutTerminalOutput(args ...string) ([]string, error) {
+
+	output, err := connection.CliConnection.CliCommandWithoutTerminalOutput(args...)
+	if err != nil || len(args) == 0 || args[0] != "curl" {
+		return output, err
+	}
+
+	body := output
+	if containsString(args, "-i") {
+		status, _, content := splitCurlResponse(output)
+		// A 304 has no body worth keeping; the earlier 200 already is.
+		if status == "304" {
+			return output, err
+		}
+		body = []string{content}
+	}
+
+	connection.record(curlPath(args), body)
+	return output, err
+}
+
+func (connection *recordingConnection) record(path string, body []string) {
+
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	connection.count++
+	file := fmt.Sprintf("%05d.json", connection.count)
+	if err := ioutil.WriteFile(filepath.Join(connection.dir, file), []byte(strings.Join(body, "\n")), 0644); err != nil {
+		fmt.Fprintf(progressOutput, "Recording %s failed: %v\n", path, err)
+		return
+	}
+
+	line, _ := json.Marshal(recordedRequest{Path: path, File: file})
+	connection.index.Write(append(line, '\n'))
+}
+
+// loadRecording turns a --record directory back into a fixture.
+func loadRecording(dir string) (*fixtureConnection, error) {
+
+	connection := &fixtureConnection{}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "meta.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &connection.fixture); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Join(dir, "meta.json"), err)
+	}
+	connection.fixture.Responses = map[string]json.RawMessage{}
+
+	index, err := os.Open(filepath.Join(dir, "requests.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	defer index.Close()
+
+	scanner := bufio.NewScanner(index)
+	for scanner.Scan() {
+		request := recordedRequest{}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			continue
+		}
+		body, err := ioutil.ReadFile(filepath.Join(dir, request.File))
+		if err != nil {
+			return nil, err
+		}
+		connection.fixture.Responses[request.Path] = json.RawMessage(body)
+	}
+
+	return connection, scanner.Err()
+}
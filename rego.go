@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+)
+
+type regoResult struct {
+	GUID        string   `json:"guid"`
+	ProcessType string   `json:"process_type"`
+	Deny        []string `json:"deny"`
+	Warn        []string `json:"warn"`
+}
+
+// key matches appStatSummary.Key, so --processes rows are judged apart.
+func (result *regoResult) key() string {
+	if result.ProcessType == "" {
+		return result.GUID
+	}
+	return result.GUID + "/" + result.ProcessType
+}
+
+type regoOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value []regoResult `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// regoInput is the record each app is evaluated as: the JSON report fields
+// plus the derived figures the table shows.
+func regoInput(appStats []appStatSummary) ([]map[string]interface{}, error) {
+
+	var input []map[string]interface{}
+	for i := range appStats {
+		app := &appStats[i]
+
+		data, err := json.Marshal(app)
+		if err != nil {
+			return nil, err
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+
+		record["waste"] = app.Waste()
+		record["savings"] = app.Savings()
+		record["recommended_alloc"] = app.RecommendedAlloc()
+		record["util"] = app.Utilization()
+		input = append(input, record)
+	}
+
+	return input, nil
+}
+
+// evaluateRego runs the policy through the opa binary in a single pass,
+// binding each app as input in turn and collecting its deny and warn sets.
+func evaluateRego(opts *options, appStats []appStatSummary) ([]regoResult, error) {
+
+	input, err := regoInput(appStats)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`[r | app := input[_]
+		deny := {m | m := data.%[1]s.deny[_]} with input as app
+		warn := {m | m := data.%[1]s.warn[_]} with input as app
+		r := {"guid": app.guid, "process_type": object.get(app, "process_type", ""), "deny": deny, "warn": warn}]`, opts.regoPackage)
+
+	cmd := exec.Command("opa", "eval", "--format", "json", "--data", opts.regoPolicy, "--stdin-input", query)
+	cmd.Stdin = bytes.NewReader(data)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("opa eval failed: %v %s", err, stderr.String())
+	}
+
+	res := regoOutput{}
+	if err := json.Unmarshal(output, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Result) == 0 || len(res.Result[0].Expressions) == 0 {
+		return nil, fmt.Errorf("opa eval returned no result for package %s", opts.regoPackage)
+	}
+
+	return res.Result[0].Expressions[0].Value, nil
+}
+
+func renderRegoResults(w io.Writer, appStats []appStatSummary, results []regoResult) (denied int) {
+
+	byKey := map[string]*appStatSummary{}
+	for i := range appStats {
+		byKey[appStats[i].Key()] = &appStats[i]
+	}
+
+	for _, result := range results {
+		if len(result.Deny) == 0 && len(result.Warn) == 0 {
+			continue
+		}
+		app := byKey[result.key()]
+		if app == nil {
+			continue
+		}
+
+		sort.Strings(result.Deny)
+		sort.Strings(result.Warn)
+		for _, msg := range result.Deny {
+			fmt.Fprintf(w, "DENY  %s (%s/%s): %s\n", processAppName(*app), app.Org, app.SpaceName, msg)
+		}
+		for _, msg := range result.Warn {
+			fmt.Fprintf(w, "WARN  %s (%s/%s): %s\n", processAppName(*app), app.Org, app.SpaceName, msg)
+		}
+		if len(result.Deny) > 0 {
+			denied++
+		}
+	}
+
+	return denied
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderRegoResultsMatchesProcessRows(t *testing.T) {
+
+	appStats := []appStatSummary{
+		{GUID: "app-1", Name: "api", Org: "acme", SpaceName: "prod", ProcessType: "web"},
+		{GUID: "app-1", Name: "api", Org: "acme", SpaceName: "prod", ProcessType: "worker"},
+	}
+	results := []regoResult{
+		{GUID: "app-1", ProcessType: "web", Deny: []string{"too big"}},
+		{GUID: "app-1", ProcessType: "worker"},
+	}
+
+	buffer := &bytes.Buffer{}
+	if denied := renderRegoResults(buffer, appStats, results); denied != 1 {
+		t.Errorf("expected one denied row, got %d", denied)
+	}
+	if !strings.Contains(buffer.String(), "DENY  api (web) (acme/prod): too big") {
+		t.Errorf("expected the web row to be denied:\n%s", buffer)
+	}
+}
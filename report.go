@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/danhigham/hall-of-shame/pkg/render"
+	"github.com/olekukonko/tablewriter"
+)
+
+type reportFormat struct {
+	extension   string
+	contentType string
+	render      func(io.Writer, []appStatSummary) error
+}
+
+var reportFormats = map[string]reportFormat{
+	"json": {"json", "application/json", renderJSON},
+	"csv":  {"csv", "text/csv", renderCSV},
+
+	"openmetrics": {"prom", "application/openmetrics-text; version=1.0.0", renderOpenMetrics},
+}
+
+type tableColumn struct {
+	header string
+	value  func(*appStatSummary) string
+}
+
+var optionalColumns = map[string]tableColumn{
+	"org":    {"Org", func(s *appStatSummary) string { return s.Org }},
+	"owners": {"Owners", func(s *appStatSummary) string { return strings.Join(s.Owners, ", ") }},
+	"team":   {"Team", func(s *appStatSummary) string { return s.Team }},
+
+	"pushed-by": {"Pushed By", func(s *appStatSummary) string { return s.PushedBy }},
+	"stack":     {"Stack", func(s *appStatSummary) string { return s.Stack }},
+	"buildpack": {"Buildpack", func(s *appStatSummary) string { return s.Buildpack }},
+	"route":     {"Route", firstRoute},
+	"routes":    {"Routes", func(s *appStatSummary) string { return fmt.Sprintf("%d", len(s.Routes)) }},
+	"age":       {"Age", func(s *appStatSummary) string { return formatAge(s.CreatedAt) }},
+	"updated":   {"Updated", func(s *appStatSummary) string { return formatAge(s.UpdatedAt) }},
+	"state":     {"State", func(s *appStatSummary) string { return s.State }},
+
+	"bad-instances": {"Bad Instances", func(s *appStatSummary) string { return fmt.Sprintf("%d", s.BadInstances) }},
+	"last-crash":    {"Last Crash", func(s *appStatSummary) string { return s.LastCrash }},
+	"space-median":  {"vs Space", quotaVsSpace},
+	"alignment":     {"Aligned Quota", quotaAlignment},
+	"disk-ratio":    {"DiskRatio", func(s *appStatSummary) string { return tableNumbers.float(s.DiskRatio) }},
+	"util":          {"Util%", func(s *appStatSummary) string { return fmt.Sprintf("%.0f%%", s.Utilization()) }},
+	"rps":           {"RPS", func(s *appStatSummary) string { return tableNumbers.float(s.RPS) }},
+	"autoscaled":    {"AS", autoscaledFlag},
+	"tasks":         {"Tasks", taskUsage},
+	"process":       {"Process", processLabel},
+}
+
+func processLabel(s *appStatSummary) string {
+	if s.SidecarAlloc > 0 {
+		return fmt.Sprintf("%s (%s of quota)", s.ProcessType, formatSize(s.SidecarAlloc))
+	}
+	return s.ProcessType
+}
+
+func setAppsManagerLinks(opts *options, appStats []appStatSummary) {
+	if opts.appsManagerURL == "" {
+		return
+	}
+
+	base := strings.TrimSuffix(opts.appsManagerURL, "/")
+	for i := range appStats {
+		app := &appStats[i]
+		if app.OrgGUID == "" {
+			continue
+		}
+		app.AppsManagerURL = fmt.Sprintf("%s/organizations/%s/spaces/%s/applications/%s", base, app.OrgGUID, app.Space, app.GUID)
+	}
+}
+
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%dd", int(time.Since(t).Hours()/24))
+}
+
+func autoscaledFlag(s *appStatSummary) string {
+	if s.Autoscaled {
+		return "AS"
+	}
+	return ""
+}
+
+func taskUsage(s *appStatSummary) string {
+	if s.Tasks == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d (%.1f GB-h)", s.Tasks, s.TaskGBHours)
+}
+
+func firstRoute(s *appStatSummary) string {
+	switch len(s.Routes) {
+	case 0:
+		return ""
+	case 1:
+		return s.Routes[0]
+	default:
+		return fmt.Sprintf("%s (+%d)", s.Routes[0], len(s.Routes)-1)
+	}
+}
+
+func renderReport(w io.Writer, opts *options, appStats []appStatSummary) error {
+	if opts.output == "table" {
+		var measured, idle, venerable []appStatSummary
+		for _, app := range appStats {
+			switch {
+			case app.Venerable:
+				venerable = append(venerable, app)
+			case app.NoUsage:
+				idle = append(idle, app)
+			default:
+				measured = append(measured, app)
+			}
+		}
+
+		if err := renderTable(w, opts, measured); err != nil {
+			return err
+		}
+		if len(idle) > 0 {
+			fmt.Fprintf(w, "\nNo data / idle (%d apps reporting zero memory usage):\n", len(idle))
+			if err := renderTable(w, opts, idle); err != nil {
+				return err
+			}
+		}
+		if len(venerable) > 0 {
+			fmt.Fprintf(w, "\nLeft behind by blue-green deploys (%d apps, usually safe to delete):\n", len(venerable))
+			return renderTable(w, opts, venerable)
+		}
+		return nil
+	}
+
+	if opts.output == "junit" {
+		return renderJUnit(w, opts, appStats)
+	}
+
+	// The step summary itself is written once, by Run, for the whole
+	// report; here, as for per-team reports, gha-summary is a plain table.
+	if opts.output == "gha-summary" {
+		return renderTable(w, opts, appStats)
+	}
+
+	report, ok := reportFormats[opts.output]
+	if !ok {
+		return fmt.Errorf("unknown report format %q", opts.output)
+	}
+	return report.render(w, appStats)
+}
+
+func renderTable(w io.Writer, opts *options, appStats []appStatSummary) error {
+	header := []string{"Name", "Space", "Alloc", "AvgUse", "Ratio"}
+	for _, name := range opts.columns {
+		header = append(header, optionalColumns[name].header)
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	if opts.wide {
+		table.SetAutoWrapText(false)
+	} else {
+		table.SetColWidth(opts.maxColWidth)
+	}
+
+	for _, v := range appStats {
+		row := toValueList(&v)
+		for _, name := range opts.columns {
+			row = append(row, optionalColumns[name].value(&v))
+		}
+		if !opts.wide {
+			for i := range row {
+				row[i] = truncateCell(row[i], opts.maxColWidth)
+			}
+		}
+		table.Append(row)
+	}
+
+	table.Render()
+	return nil
+}
+
+// truncateCell shortens table cells only; JSON and CSV keep full values.
+func truncateCell(value string, width int) string {
+	runes := []rune(value)
+	if width < 2 || len(runes) <= width {
+		return value
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func renderJSON(w io.Writer, appStats []appStatSummary) error {
+	return render.JSON(w, reportMeta, appStats)
+}
+
+func renderCSV(w io.Writer, appStats []appStatSummary) error {
+	return render.CSV(w, appStats, tableNumbers.decimals)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+// fixtureReport collects testdata/run.json the way Run does, up to the
+// point where a report is rendered.
+func fixtureReport(t *testing.T, args ...string) (*options, []appStatSummary) {
+
+	t.Setenv("HOME", t.TempDir())
+	progressOutput = ioutil.Discard
+
+	opts, err := parseOptions(append([]string{"--fixture", "testdata/run.json"}, args...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, err := loadFixture(opts.fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cliConnection := meteredConnection{fixture}
+
+	configureNameCache(opts)
+	if err := configureRates(cliConnection, opts); err != nil {
+		t.Fatal(err)
+	}
+	tableNumbers = newNumberFormat(opts.locale, opts.decimals)
+
+	appStats, err := new(HallOfShame).collect(cliConnection, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appStats = applyFilters(opts, appStats)
+	sort.Stable(byRatio(appStats))
+	return opts, appStats
+}
+
+// checkGolden compares output with testdata/golden/<name>, or rewrites it
+// under go test -update.
+func checkGolden(t *testing.T, path string, output []byte) {
+
+	if *update {
+		if err := ioutil.WriteFile(path, output, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, want) {
+		t.Errorf("%s differs from the output (go test -update rewrites it):\n%s", path, output)
+	}
+}
+
+var lastRunTimestamp = regexp.MustCompile(`(?m)^(hall_of_shame_last_run_timestamp_seconds) \d+$`)
+
+func TestRenderGolden(t *testing.T) {
+
+	for _, output := range []string{"table", "openmetrics", "junit", "gha-summary"} {
+		t.Run(output, func(t *testing.T) {
+
+			opts, appStats := fixtureReport(t, "--output", output)
+
+			buffer := &bytes.Buffer{}
+			switch output {
+			case "gha-summary":
+				// writeStepSummary only adds the $GITHUB_STEP_SUMMARY plumbing.
+				renderStepSummary(buffer, opts, appStats, nil)
+			default:
+				if err := renderReport(buffer, opts, appStats); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rendered := lastRunTimestamp.ReplaceAll(buffer.Bytes(), []byte("$1 0"))
+			checkGolden(t, filepath.Join("testdata", "golden", output+".golden"), rendered)
+		})
+	}
+}
+
+// TestRenderInputGolden keeps pkg/render/testdata/apps.json, the input of
+// the JSON and CSV golden tests, in step with what testdata/run.json
+// collects to.
+func TestRenderInputGolden(t *testing.T) {
+
+	_, appStats := fixtureReport(t)
+
+	data, err := json.MarshalIndent(appStats, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, filepath.Join("pkg", "render", "testdata", "apps.json"), append(data, '\n'))
+}
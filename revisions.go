@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+type revisionSummary struct {
+	Name        string
+	Org         string
+	SpaceName   string
+	Revisions   int
+	Droplets    int
+	Reclaimable int64
+}
+
+// collectRevisions counts revisions and staged droplets, treating every
+// droplet other than the current one as reclaimable blobstore space.
+func (hallOfShame *HallOfShame) collectRevisions(cliConnection plugin.CliConnection, client *blobClient, cfApp *AppSearchResoures) (*revisionSummary, error) {
+
+	summary := &revisionSummary{Name: cfApp.Entity.Name}
+
+	revisions, err := hallOfShame.v3Resources(cliConnection, fmt.Sprintf("/v3/apps/%v/revisions?per_page=5000", cfApp.Metadata.Guid))
+	if err != nil {
+		return nil, err
+	}
+	summary.Revisions = len(revisions.Resources)
+
+	droplets, err := hallOfShame.v3Resources(cliConnection, fmt.Sprintf("/v3/apps/%v/droplets?states=STAGED&per_page=5000", cfApp.Metadata.Guid))
+	if err != nil {
+		return nil, err
+	}
+	summary.Droplets = len(droplets.Resources)
+
+	current, _ := hallOfShame.GetCurrentDroplet(cliConnection, cfApp.Metadata.Guid)
+	for _, droplet := range droplets.Resources {
+		if droplet.Guid == current {
+			continue
+		}
+		if size, err := client.size(fmt.Sprintf("/v3/droplets/%v/download", droplet.Guid)); err == nil {
+			summary.Reclaimable += size
+		}
+	}
+
+	return summary, nil
+}
+
+func (hallOfShame *HallOfShame) runRevisionsCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	client, err := newBlobClient(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	var apps []*revisionSummary
+	var mutex sync.Mutex
+
+	bar := newProgressBar(len(res.Resources))
+	bar.Start()
+
+	wg := sizedwaitgroup.New(2)
+	for _, app := range res.Resources {
+		wg.Add()
+
+		go func(cfApp *AppSearchResoures) {
+			defer wg.Done()
+
+			summary, err := hallOfShame.collectRevisions(cliConnection, client, cfApp)
+			bar.Increment()
+			if err != nil {
+				return
+			}
+
+			spaceNames := names.resolve(cfApp.Entity.SpaceGuid)
+			summary.SpaceName, summary.Org = spaceNames.space, spaceNames.org
+
+			mutex.Lock()
+			apps = append(apps, summary)
+			mutex.Unlock()
+		}(app)
+	}
+
+	wg.Wait()
+	bar.FinishPrint("Done!")
+
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Revisions > apps[j].Revisions })
+
+	renderRevisions(os.Stdout, apps, opts.minRevisions)
+	return nil
+}
+
+func renderRevisions(w io.Writer, apps []*revisionSummary, minRevisions int) {
+
+	orgs := map[string]int64{}
+	var orgNames []string
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Revisions", "Droplets", "Reclaimable"})
+
+	for _, app := range apps {
+		if _, ok := orgs[app.Org]; !ok {
+			orgNames = append(orgNames, app.Org)
+		}
+		orgs[app.Org] += app.Reclaimable
+
+		if app.Revisions < minRevisions && app.Droplets < minRevisions {
+			continue
+		}
+		table.Append([]string{
+			app.Name,
+			app.Org,
+			app.SpaceName,
+			fmt.Sprintf("%d", app.Revisions),
+			fmt.Sprintf("%d", app.Droplets),
+			formatSize(int(app.Reclaimable)),
+		})
+	}
+
+	table.Render()
+
+	sort.Strings(orgNames)
+
+	fmt.Fprintln(w, "\nReclaimable droplet space per org:")
+	orgTable := tablewriter.NewWriter(w)
+	orgTable.SetHeader([]string{"Org", "Reclaimable"})
+	for _, name := range orgNames {
+		orgTable.Append([]string{name, formatSize(int(orgs[name]))})
+	}
+	orgTable.Render()
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// configSchema describes the shape of the JSON files the plugin reads, so
+// typos are reported with their position instead of being ignored.
+type configSchema struct {
+	kind   string
+	fields map[string]*configSchema
+	values *configSchema
+}
+
+var (
+	stringSchema = &configSchema{kind: "string"}
+	numberSchema = &configSchema{kind: "number"}
+
+	budgetsSchema = &configSchema{kind: "object", values: &configSchema{kind: "object", fields: map[string]*configSchema{
+		"memory": stringSchema,
+		"cost":   numberSchema,
+	}}}
+
+	stringMapSchema = &configSchema{kind: "object", values: stringSchema}
+)
+
+type schemaValidator struct {
+	name    string
+	data    []byte
+	decoder *json.Decoder
+	errors  []string
+}
+
+// validateConfig checks data against schema and returns every problem at
+// once, each prefixed with file:line:column.
+func validateConfig(name string, data []byte, schema *configSchema) error {
+
+	validator := &schemaValidator{name: name, data: data, decoder: json.NewDecoder(bytes.NewReader(data))}
+	validator.decoder.UseNumber()
+
+	if err := validator.walk(schema, ""); err != nil {
+		return fmt.Errorf("%s: %v", validator.position(validator.decoder.InputOffset()), err)
+	}
+	if len(validator.errors) > 0 {
+		return fmt.Errorf("invalid %s:\n  %s", name, strings.Join(validator.errors, "\n  "))
+	}
+	return nil
+}
+
+func (validator *schemaValidator) position(offset int64) string {
+	before := validator.data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return fmt.Sprintf("%s:%d:%d", validator.name, line, column)
+}
+
+// nextTokenOffset is where the next token starts. InputOffset points just
+// past the previous one, which for a key or value is still on the line
+// before, so skip the whitespace and separators in between.
+func (validator *schemaValidator) nextTokenOffset() int64 {
+	offset := validator.decoder.InputOffset()
+	for offset < int64(len(validator.data)) && strings.IndexByte(" \t\r\n,:", validator.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func (validator *schemaValidator) fail(offset int64, format string, args ...interface{}) {
+	validator.errors = append(validator.errors, validator.position(offset)+": "+fmt.Sprintf(format, args...))
+}
+
+func (validator *schemaValidator) walk(schema *configSchema, path string) error {
+
+	offset := validator.nextTokenOffset()
+	token, err := validator.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch schema.kind {
+	case "string":
+		if _, ok := token.(string); !ok {
+			validator.fail(offset, "%s must be a string", strings.TrimPrefix(path, "."))
+			return validator.skip(token)
+		}
+	case "number":
+		if _, ok := token.(json.Number); !ok {
+			validator.fail(offset, "%s must be a number", strings.TrimPrefix(path, "."))
+			return validator.skip(token)
+		}
+	case "object":
+		if delim, ok := token.(json.Delim); !ok || delim != '{' {
+			validator.fail(offset, "%s must be an object", strings.TrimPrefix(path, "."))
+			return validator.skip(token)
+		}
+
+		for validator.decoder.More() {
+			keyOffset := validator.nextTokenOffset()
+			keyToken, err := validator.decoder.Token()
+			if err != nil {
+				return err
+			}
+			key := keyToken.(string)
+			childPath := path + "." + key
+
+			child := schema.values
+			if schema.fields != nil {
+				child = schema.fields[key]
+			}
+			if child == nil {
+				validator.fail(keyOffset, "unknown field %q%s", strings.TrimPrefix(childPath, "."), suggestField(key, schema.fields))
+				if err := validator.skipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := validator.walk(child, childPath); err != nil {
+				return err
+			}
+		}
+
+		if _, err := validator.decoder.Token(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (validator *schemaValidator) skipValue() error {
+	token, err := validator.decoder.Token()
+	if err != nil {
+		return err
+	}
+	return validator.skip(token)
+}
+
+// skip consumes the rest of a value whose first token has been read.
+func (validator *schemaValidator) skip(token json.Token) error {
+	delim, ok := token.(json.Delim)
+	if !ok || delim == '}' || delim == ']' {
+		return nil
+	}
+
+	for depth := 1; depth > 0; {
+		token, err := validator.decoder.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+func suggestField(key string, fields map[string]*configSchema) string {
+
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if distance := editDistance(key, name); distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf(", did you mean %q?", best)
+	}
+	if len(names) > 0 {
+		return fmt.Sprintf(" (expected one of %s)", strings.Join(names, ", "))
+	}
+	return ""
+}
+
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigUnknownFieldPosition(t *testing.T) {
+
+	data := `{
+  "acme": {
+    "memory": "64G",
+    "cots": 1200
+  }
+}
+`
+	err := validateConfig("budgets.json", []byte(data), budgetsSchema)
+	if err == nil {
+		t.Fatal("expected an error for the misspelled field")
+	}
+	want := `budgets.json:4:5: unknown field "acme.cots", did you mean "cost"?`
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestValidateConfigWrongTypePosition(t *testing.T) {
+
+	data := "{\"acme\": {\"memory\": \"64G\",\n\t\"cost\": \"lots\"}}"
+	err := validateConfig("budgets.json", []byte(data), budgetsSchema)
+	if err == nil {
+		t.Fatal("expected an error for the string cost")
+	}
+	want := "budgets.json:2:10: acme.cost must be a number"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestValidateConfigValid(t *testing.T) {
+
+	data := `{"acme": {"memory": "64G", "cost": 1200}}`
+	if err := validateConfig("budgets.json", []byte(data), budgetsSchema); err != nil {
+		t.Error(err)
+	}
+}
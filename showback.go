@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+type orgShowback struct {
+	Org      string
+	Managers []string
+	Apps     int
+	GBHours  float64
+	Cost     float64
+	Wasted   float64
+	Savings  float64
+}
+
+func (hallOfShame *HallOfShame) runShowbackCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	if !costRates.configured() {
+		return fmt.Errorf("showback requires --rate-gb-hour or --rates")
+	}
+
+	apps, err := hallOfShame.collectMemoryHours(cliConnection, opts)
+	if err != nil {
+		return err
+	}
+
+	// Normalise the window to a month so the figures read as monthly cost.
+	monthly := hoursPerMonth / opts.usageWindow.Hours()
+
+	orgs := map[string]*orgShowback{}
+	for _, app := range apps {
+		org, ok := orgs[app.Org]
+		if !ok {
+			org = &orgShowback{Org: app.Org}
+			if managers, err := hallOfShame.GetOrgManagers(cliConnection, app.OrgGUID); err == nil {
+				sort.Strings(managers)
+				org.Managers = managers
+			}
+			orgs[app.Org] = org
+		}
+		// Disk and per-instance overhead are priced on the app's current
+		// footprint; memory on the usage history.
+		rate := costRates.forSegment(app.IsolationSegment)
+		instances := float64(app.Instances)
+		org.Apps++
+		org.GBHours += app.Allocated * monthly
+		org.Cost += app.Allocated*monthly*rate.MemoryGBHour +
+			float64(app.DiskAlloc)/(1<<30)*instances*rate.DiskGBMonth +
+			instances*rate.InstanceMonth
+		org.Wasted += app.Unused * monthly * rate.MemoryGBHour
+		org.Savings += app.Reclaimable * monthly * rate.MemoryGBHour
+	}
+
+	currency := costRates.currency(opts.currency)
+
+	var rows []*orgShowback
+	for _, org := range orgs {
+		rows = append(rows, org)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Cost > rows[j].Cost })
+
+	if opts.output == "csv" {
+		return renderShowbackCSV(os.Stdout, rows, currency)
+	}
+
+	renderShowbackTable(os.Stdout, rows, currency)
+	return nil
+}
+
+func renderShowbackTable(w io.Writer, rows []*orgShowback, currency string) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Org", "Managers", "Apps", "GB-h / month", "Monthly Cost", "Wasted Cost", "Projected Savings"})
+
+	var cost, wasted, savings float64
+	for _, org := range rows {
+		table.Append([]string{
+			org.Org,
+			strings.Join(org.Managers, ", "),
+			fmt.Sprintf("%d", org.Apps),
+			fmt.Sprintf("%.1f", org.GBHours),
+			fmt.Sprintf("%.2f %s", org.Cost, currency),
+			fmt.Sprintf("%.2f %s", org.Wasted, currency),
+			fmt.Sprintf("%.2f %s", org.Savings, currency),
+		})
+		cost += org.Cost
+		wasted += org.Wasted
+		savings += org.Savings
+	}
+
+	table.SetFooter([]string{"Total", "", "", "", fmt.Sprintf("%.2f %s", cost, currency), fmt.Sprintf("%.2f %s", wasted, currency), fmt.Sprintf("%.2f %s", savings, currency)})
+	table.Render()
+}
+
+func renderShowbackCSV(w io.Writer, rows []*orgShowback, currency string) error {
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"org", "org_managers", "apps", "gb_hours_per_month", "currency", "monthly_cost", "wasted_cost", "projected_savings"})
+
+	for _, org := range rows {
+		writer.Write([]string{
+			org.Org,
+			strings.Join(org.Managers, ";"),
+			fmt.Sprintf("%d", org.Apps),
+			fmt.Sprintf("%.2f", org.GBHours),
+			currency,
+			fmt.Sprintf("%.2f", org.Cost),
+			fmt.Sprintf("%.2f", org.Wasted),
+			fmt.Sprintf("%.2f", org.Savings),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
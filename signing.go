@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// reportSigner signs report artifacts so they can stand as evidence that a
+// capacity review ran and what it found. Signatures verify with openssl:
+//
+//	openssl dgst -sha256 -verify key.pub -signature report.json.sig report.json
+//
+// or, for Ed25519 keys, openssl pkeyutl -verify -rawin.
+type reportSigner struct {
+	key crypto.Signer
+}
+
+// loadSigner reads a PEM private key: PKCS#8 (RSA, ECDSA or Ed25519),
+// PKCS#1 RSA or SEC 1 EC.
+func loadSigner(file string) (*reportSigner, error) {
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM private key", file)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return &reportSigner{key: key.(crypto.Signer)}, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported key type %T", file, key)
+}
+
+func (signer *reportSigner) sign(body []byte) ([]byte, error) {
+	if _, ok := signer.key.(ed25519.PrivateKey); ok {
+		return signer.key.Sign(rand.Reader, body, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(body)
+	return signer.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// signatureFiles returns the detached files that accompany an artifact
+// named name: <name>.sha256 in sha256sum format and the <name>.sig
+// signature.
+func signatureFiles(signer *reportSigner, name string, body []byte) (map[string][]byte, error) {
+
+	digest := sha256.Sum256(body)
+	files := map[string][]byte{
+		name + ".sha256": []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(digest[:]), filepath.Base(name))),
+	}
+
+	signature, err := signer.sign(body)
+	if err != nil {
+		return nil, err
+	}
+	files[name+".sig"] = signature
+
+	return files, nil
+}
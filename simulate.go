@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const sharedSegment = "shared"
+
+type rightsizeTotals struct {
+	Apps        int
+	Instances   int
+	Allocated   int
+	Recommended int
+}
+
+func (totals *rightsizeTotals) add(app appStatSummary) {
+	totals.Apps++
+	totals.Instances += app.Instances
+	totals.Allocated += app.MemoryAlloc * app.Instances
+	totals.Recommended += (app.MemoryAlloc * app.Instances) - app.Savings()
+}
+
+func (totals *rightsizeTotals) reclaimed() int {
+	return totals.Allocated - totals.Recommended
+}
+
+func renderSimulation(w io.Writer, appStats []appStatSummary) {
+
+	platform := &rightsizeTotals{}
+	orgs := map[string]*rightsizeTotals{}
+	segments := map[string]*rightsizeTotals{}
+
+	var skipped int
+	for _, app := range appStats {
+		// Stopped apps hold no memory and idle ones have no usage to size
+		// from, so neither counts towards what rightsizing would reclaim.
+		if app.NoUsage || app.State == "STOPPED" {
+			skipped++
+			continue
+		}
+		platform.add(app)
+
+		if orgs[app.Org] == nil {
+			orgs[app.Org] = &rightsizeTotals{}
+		}
+		orgs[app.Org].add(app)
+
+		segment := app.IsolationSegment
+		if segment == "" {
+			segment = sharedSegment
+		}
+		if segments[segment] == nil {
+			segments[segment] = &rightsizeTotals{}
+		}
+		segments[segment].add(app)
+	}
+
+	if platform.Instances == 0 {
+		return
+	}
+	averageInstance := platform.Allocated / platform.Instances
+
+	fmt.Fprintf(w, "\nRightsizing simulation:\n")
+	fmt.Fprintf(w, "  Allocated today:     %s\n", formatSize(platform.Allocated))
+	fmt.Fprintf(w, "  After rightsizing:   %s\n", formatSize(platform.Recommended))
+	fmt.Fprintf(w, "  Reclaimed:           %s\n", formatSize(platform.reclaimed()))
+	if averageInstance > 0 {
+		fmt.Fprintf(w, "  Room for about %d more average-sized (%s) instances\n", platform.reclaimed()/averageInstance, formatSize(averageInstance))
+	}
+	if skipped > 0 {
+		fmt.Fprintf(w, "  %d stopped or idle apps left out\n", skipped)
+	}
+	fmt.Fprintln(w)
+
+	renderRightsizeBreakdown(w, "Org", orgs)
+	renderRightsizeBreakdown(w, "Isolation Segment", segments)
+}
+
+func renderRightsizeBreakdown(w io.Writer, label string, groups map[string]*rightsizeTotals) {
+
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return groups[names[i]].reclaimed() > groups[names[j]].reclaimed() })
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{label, "Apps", "Allocated", "After", "Reclaimed"})
+	for _, name := range names {
+		group := groups[name]
+		table.Append([]string{
+			name,
+			fmt.Sprintf("%d", group.Apps),
+			formatSize(group.Allocated),
+			formatSize(group.Recommended),
+			formatSize(group.reclaimed()),
+		})
+	}
+	table.Render()
+}
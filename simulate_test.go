@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderSimulationSkipsStoppedAndIdle(t *testing.T) {
+
+	appStats := []appStatSummary{
+		{Name: "busy", Org: "acme", State: "RUNNING", Instances: 1, MemoryAlloc: 1 << 30, AvgMemoryUse: 256 << 20, Ratio: 4},
+		{Name: "parked", Org: "acme", State: "STOPPED", Instances: 4, MemoryAlloc: 4 << 30},
+		{Name: "quiet", Org: "acme", State: "RUNNING", Instances: 2, MemoryAlloc: 2 << 30, NoUsage: true},
+	}
+
+	buffer := &bytes.Buffer{}
+	renderSimulation(buffer, appStats)
+	output := buffer.String()
+
+	if !strings.Contains(output, "Allocated today:     1.0G") {
+		t.Errorf("only busy should count towards the allocation:\n%s", output)
+	}
+	if !strings.Contains(output, "2 stopped or idle apps left out") {
+		t.Errorf("expected the skipped apps to be reported:\n%s", output)
+	}
+}
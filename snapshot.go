@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+)
+
+type snapshot struct {
+	TakenAt time.Time        `json:"taken_at"`
+	Apps    []appStatSummary `json:"apps"`
+}
+
+func saveSnapshot(path string, appStats []appStatSummary) error {
+	data, err := json.MarshalIndent(snapshot{TakenAt: time.Now().UTC(), Apps: appStats}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func loadSnapshot(path string) (*snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{}
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
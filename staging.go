@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+type V3BuildResults struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []struct {
+		Guid              string `json:"guid"`
+		State             string `json:"state"`
+		CreatedAt         string `json:"created_at"`
+		StagingMemoryInMb int    `json:"staging_memory_in_mb"`
+		StagingDiskInMb   int    `json:"staging_disk_in_mb"`
+		App               struct {
+			Guid string `json:"guid"`
+		} `json:"app"`
+	} `json:"resources"`
+}
+
+type stagingSummary struct {
+	Name          string
+	Org           string
+	SpaceName     string
+	Memory        int
+	Disk          int
+	StagingMemory int
+	StagingDisk   int
+}
+
+func (summary *stagingSummary) memoryRatio() float64 {
+	if summary.Memory == 0 {
+		return 0
+	}
+	return float64(summary.StagingMemory) / float64(summary.Memory)
+}
+
+func (summary *stagingSummary) diskRatio() float64 {
+	if summary.Disk == 0 {
+		return 0
+	}
+	return float64(summary.StagingDisk) / float64(summary.Disk)
+}
+
+// GetLatestBuilds returns each app's most recent staged build, from one
+// paged listing of every build the user can see, newest first.
+func (hallOfShame *HallOfShame) GetLatestBuilds(cliConnection plugin.CliConnection) (map[string]int, map[string]int, error) {
+
+	memory, disk := map[string]int{}, map[string]int{}
+
+	query := "/v3/builds?states=STAGED&order_by=-created_at&per_page=5000"
+	for query != "" {
+		output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		res := V3BuildResults{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			return nil, nil, err
+		}
+
+		for _, build := range res.Resources {
+			if _, seen := memory[build.App.Guid]; seen {
+				continue
+			}
+			memory[build.App.Guid] = build.StagingMemoryInMb << 20
+			disk[build.App.Guid] = build.StagingDiskInMb << 20
+		}
+
+		query = ""
+		if res.Pagination.Next != nil {
+			query = v3RequestPath(res.Pagination.Next.Href)
+		}
+	}
+
+	return memory, disk, nil
+}
+
+// runStagingCommand lists apps whose last staging container asked for far
+// more memory or disk than the app runs with. Staging containers are placed
+// on cells like any other, so oversized ones crowd out capacity during busy
+// deploy windows.
+func (hallOfShame *HallOfShame) runStagingCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	res, err := hallOfShame.GetAllApps(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	stagingMemory, stagingDisk, err := hallOfShame.GetLatestBuilds(cliConnection)
+	if err != nil {
+		return err
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	var apps []*stagingSummary
+	for _, cfApp := range res.Resources {
+		memory, ok := stagingMemory[cfApp.Metadata.Guid]
+		if !ok {
+			continue
+		}
+
+		summary := &stagingSummary{
+			Name:          cfApp.Entity.Name,
+			Memory:        cfApp.Entity.Memory << 20,
+			Disk:          cfApp.Entity.DiskQuota << 20,
+			StagingMemory: memory,
+			StagingDisk:   stagingDisk[cfApp.Metadata.Guid],
+		}
+		if summary.memoryRatio() < opts.stagingRatio && summary.diskRatio() < opts.stagingRatio {
+			continue
+		}
+
+		spaceNames := names.resolve(cfApp.Entity.SpaceGuid)
+		summary.SpaceName, summary.Org = spaceNames.space, spaceNames.org
+		apps = append(apps, summary)
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].StagingMemory-apps[i].Memory > apps[j].StagingMemory-apps[j].Memory
+	})
+
+	renderStaging(os.Stdout, apps, opts.stagingRatio)
+	return nil
+}
+
+func renderStaging(w io.Writer, apps []*stagingSummary, ratio float64) {
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Memory", "Staging Memory", "Disk", "Staging Disk"})
+
+	var excess int
+	for _, app := range apps {
+		table.Append([]string{
+			app.Name,
+			app.Org,
+			app.SpaceName,
+			formatSize(app.Memory),
+			fmt.Sprintf("%s (%.1fx)", formatSize(app.StagingMemory), app.memoryRatio()),
+			formatSize(app.Disk),
+			fmt.Sprintf("%s (%.1fx)", formatSize(app.StagingDisk), app.diskRatio()),
+		})
+		if app.StagingMemory > app.Memory {
+			excess += app.StagingMemory - app.Memory
+		}
+	}
+
+	table.Render()
+
+	fmt.Fprintf(w, "\n%d apps stage with at least %.1fx their runtime memory or disk, reserving %s more memory than they run with while staging.\n",
+		len(apps), ratio, formatSize(excess))
+}
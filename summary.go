@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const summaryTopOffenders = 10
+
+// writeStepSummary appends a Markdown summary to $GITHUB_STEP_SUMMARY.
+// Deltas come from --diff, or from the previous --history run when one
+// exists.
+func writeStepSummary(opts *options, appStats []appStatSummary) error {
+
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return fmt.Errorf("--output gha-summary requires $GITHUB_STEP_SUMMARY")
+	}
+
+	source := opts.diff
+	if source == "" {
+		if _, err := os.Stat(opts.historyDB); err == nil {
+			source = "last"
+		}
+	}
+
+	var previous []appStatSummary
+	if source != "" {
+		var err error
+		if previous, err = loadPrevious(opts, source); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	renderStepSummary(file, opts, appStats, previous)
+	return nil
+}
+
+func renderStepSummary(w io.Writer, opts *options, appStats []appStatSummary, previous []appStatSummary) {
+
+	var allocated, waste, savings int
+	var offenders []appStatSummary
+	for _, app := range appStats {
+		allocated += app.MemoryAlloc * app.Instances
+		waste += app.Waste()
+		savings += app.Savings()
+		if app.Ratio > opts.offenderRatio {
+			offenders = append(offenders, app)
+		}
+	}
+
+	before := map[string]appStatSummary{}
+	var previousWaste int
+	for _, app := range previous {
+		before[app.Key()] = app
+		previousWaste += app.Waste()
+	}
+
+	fmt.Fprintln(w, "## Memory hall of shame")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "| Apps | Offenders | Allocated | Waste | Projected savings |\n|---|---|---|---|---|\n")
+	wasteCell := formatSize(waste)
+	if previous != nil {
+		wasteCell += fmt.Sprintf(" (%s %s)", trendArrow(float64(waste-previousWaste)), formatSignedSize(waste-previousWaste))
+	}
+	fmt.Fprintf(w, "| %d | %d | %s | %s | %s |\n\n", len(appStats), len(offenders), formatSize(allocated), wasteCell, formatSize(savings))
+
+	sort.Slice(offenders, func(i, j int) bool { return offenders[i].Waste() > offenders[j].Waste() })
+	if len(offenders) > summaryTopOffenders {
+		offenders = offenders[:summaryTopOffenders]
+	}
+
+	if len(offenders) > 0 {
+		fmt.Fprintf(w, "### Top offenders (ratio above %.1f)\n\n", opts.offenderRatio)
+		fmt.Fprintln(w, "| App | Org / Space | Instances | Alloc | Avg use | Ratio | Waste | Δ Waste |")
+		fmt.Fprintln(w, "|---|---|---|---|---|---|---|---|")
+		for _, app := range offenders {
+			delta := "new"
+			if prev, ok := before[app.Key()]; ok {
+				delta = formatSignedSize(app.Waste() - prev.Waste())
+			} else if previous == nil {
+				delta = ""
+			}
+			fmt.Fprintf(w, "| %s | %s / %s | %d | %s | %s | %.2f | %s | %s |\n",
+				app.Name, app.Org, app.SpaceName, app.Instances, formatSize(app.MemoryAlloc), formatSize(app.AvgMemoryUse),
+				app.Ratio, formatSize(app.Waste()), delta)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if previous != nil {
+		diff := diffRuns(previous, appStats, opts.offenderRatio)
+		fmt.Fprintf(w, "**%d new offenders, %d fixed since the previous run.**\n", len(diff.NewOffenders), len(diff.Fixed))
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+// resolveTaskUsage totals the memory-hours of tasks created within the
+// window, counting tasks that are still running up to now.
+func (hallOfShame *HallOfShame) resolveTaskUsage(cliConnection plugin.CliConnection, appStats []appStatSummary, window time.Duration) error {
+
+	since := time.Now().Add(-window).UTC().Format(time.RFC3339)
+
+	tasks, err := hallOfShame.v3Resources(cliConnection, "/v3/tasks?per_page=5000&created_ats%5Bgt%5D="+url.QueryEscape(since))
+	if err != nil {
+		return err
+	}
+
+	index := map[string]*appStatSummary{}
+	for i := range appStats {
+		index[appStats[i].GUID] = &appStats[i]
+	}
+
+	for _, task := range tasks.Resources {
+		app, ok := index[task.Relationships.App.Data.Guid]
+		if !ok {
+			continue
+		}
+
+		end := task.UpdatedAt
+		if task.State == "RUNNING" || task.State == "PENDING" {
+			end = time.Now()
+		}
+
+		app.Tasks++
+		app.TaskGBHours += float64(task.MemoryInMb) / 1024 * end.Sub(task.CreatedAt).Hours()
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+const unassignedTeam = "unassigned"
+
+var teamFileUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type V3AppSearchResults struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []struct {
+		Guid     string `json:"guid"`
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	} `json:"resources"`
+}
+
+func (hallOfShame *HallOfShame) GetAppLabels(cliConnection plugin.CliConnection, labelKey string) (map[string]string, error) {
+
+	labels := map[string]string{}
+
+	query := "/v3/apps?per_page=5000&label_selector=" + url.QueryEscape(labelKey)
+	for query != "" {
+		output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+		if err != nil {
+			return nil, err
+		}
+
+		res := V3AppSearchResults{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			return nil, err
+		}
+
+		for _, app := range res.Resources {
+			labels[app.Guid] = app.Metadata.Labels[labelKey]
+		}
+
+		query = ""
+		if res.Pagination.Next != nil {
+			query = v3RequestPath(res.Pagination.Next.Href)
+		}
+	}
+
+	return labels, nil
+}
+
+func v3RequestPath(href string) string {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return parsed.RequestURI()
+}
+
+// assignTeams prefers the mapping file (keyed by app GUID, app name,
+// "org/space" or org) and falls back to the app's team label.
+func (hallOfShame *HallOfShame) assignTeams(cliConnection plugin.CliConnection, opts *options, appStats []appStatSummary) error {
+
+	mapping := map[string]string{}
+	if opts.teamMap != "" {
+		data, err := ioutil.ReadFile(opts.teamMap)
+		if err != nil {
+			return err
+		}
+		if err := validateConfig(opts.teamMap, data, stringMapSchema); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &mapping); err != nil {
+			return fmt.Errorf("reading %s: %v", opts.teamMap, err)
+		}
+	}
+
+	labels := map[string]string{}
+	if opts.teamLabel != "" {
+		var err error
+		if labels, err = hallOfShame.GetAppLabels(cliConnection, opts.teamLabel); err != nil {
+			return err
+		}
+	}
+
+	for i := range appStats {
+		app := &appStats[i]
+		for _, key := range []string{app.GUID, app.Name, app.Org + "/" + app.SpaceName, app.Org} {
+			if team, ok := mapping[key]; ok {
+				app.Team = team
+				break
+			}
+		}
+		if app.Team == "" {
+			app.Team = labels[app.GUID]
+		}
+	}
+
+	return nil
+}
+
+func writeTeamReports(opts *options, appStats []appStatSummary) error {
+
+	teams := map[string][]appStatSummary{}
+	for _, app := range appStats {
+		team := app.Team
+		if team == "" {
+			team = unassignedTeam
+		}
+		teams[team] = append(teams[team], app)
+	}
+
+	if err := os.MkdirAll(opts.perTeam, 0755); err != nil {
+		return err
+	}
+
+	extension := "txt"
+	if format, ok := reportFormats[opts.output]; ok {
+		extension = format.extension
+	} else if opts.output == "junit" {
+		extension = "xml"
+	}
+
+	for team, apps := range teams {
+		path := filepath.Join(opts.perTeam, teamFileUnsafe.ReplaceAllString(team, "_")+"."+extension)
+		if opts.compress {
+			path += ".gz"
+		}
+
+		var buffer bytes.Buffer
+		if err := renderReport(&buffer, opts, apps); err != nil {
+			return err
+		}
+
+		body := buffer.Bytes()
+		if opts.compress {
+			var err error
+			if body, err = gzipBytes(body); err != nil {
+				return err
+			}
+		}
+
+		files := map[string][]byte{path: body}
+		if opts.signer != nil {
+			signatures, err := signatureFiles(opts.signer, path, body)
+			if err != nil {
+				return err
+			}
+			for name, data := range signatures {
+				files[name] = data
+			}
+		}
+
+		for name, data := range files {
+			if err := ioutil.WriteFile(name, data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("Wrote %d team reports to %s\n", len(teams), opts.perTeam)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTeamReportsLeaveStepSummaryAlone(t *testing.T) {
+
+	dir := t.TempDir()
+	summary := filepath.Join(dir, "step-summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	opts, err := parseOptions([]string{"--output", "gha-summary", "--per-team", filepath.Join(dir, "teams")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appStats := []appStatSummary{
+		{Name: "shop", Team: "payments", Instances: 1, MemoryAlloc: 1 << 30, AvgMemoryUse: 256 << 20, Ratio: 4},
+		{Name: "blog", Team: "marketing", Instances: 1, MemoryAlloc: 1 << 30, AvgMemoryUse: 256 << 20, Ratio: 4},
+	}
+	if err := writeTeamReports(opts, appStats); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(summary); !os.IsNotExist(err) {
+		t.Errorf("per-team reports wrote to $GITHUB_STEP_SUMMARY")
+	}
+	for _, team := range []string{"payments", "marketing"} {
+		if _, err := os.Stat(filepath.Join(dir, "teams", team+".txt")); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// telemetryEvent is everything that leaves the machine when --telemetry-endpoint
+// is set: no names, GUIDs, endpoints or figures that identify a tenant.
+type telemetryEvent struct {
+	PluginVersion string `json:"plugin_version"`
+	GoVersion     string `json:"go_version"`
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	Command       string `json:"command"`
+	DurationMs    int64  `json:"duration_ms"`
+	Apps          int    `json:"apps"`
+	AppsReported  int    `json:"apps_reported"`
+	ErrorClass    string `json:"error_class,omitempty"`
+}
+
+type telemetryRun struct {
+	endpoint string
+	command  string
+	started  time.Time
+}
+
+func startTelemetry(opts *options, command string) *telemetryRun {
+	if command == "" {
+		command = "report"
+	}
+	return &telemetryRun{endpoint: opts.telemetryEndpoint, command: command, started: time.Now()}
+}
+
+// finish sends a single event and never fails the run; an unreachable
+// endpoint costs at most the client timeout.
+func (run *telemetryRun) finish(apps int, reported int, err error) {
+
+	if run == nil || run.endpoint == "" {
+		return
+	}
+
+	body, _ := json.Marshal(telemetryEvent{
+		PluginVersion: versionString(pluginVersion),
+		GoVersion:     runtime.Version(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Command:       run.command,
+		DurationMs:    int64(time.Since(run.started) / time.Millisecond),
+		Apps:          apps,
+		AppsReported:  reported,
+		ErrorClass:    errorClass(err),
+	})
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Post(run.endpoint, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// errorClass buckets an error without reporting its message, which may name
+// orgs, spaces or hosts.
+func errorClass(err error) string {
+
+	if err == nil {
+		return ""
+	}
+
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "not logged in"), strings.Contains(message, "401"), strings.Contains(message, "token"):
+		return "auth"
+	case strings.Contains(message, "403"):
+		return "forbidden"
+	case strings.Contains(message, "timeout"), strings.Contains(message, "deadline exceeded"):
+		return "timeout"
+	case strings.Contains(message, "connection refused"), strings.Contains(message, "no such host"):
+		return "network"
+	case strings.Contains(message, "json"), strings.Contains(message, "unexpected end"):
+		return "decode"
+	}
+	return "other"
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var uiTemplates = template.Must(template.New("layout").Funcs(template.FuncMap{
+	"size":     formatSize,
+	"path":     url.PathEscape,
+	"metadata": func() reportMetadata { return reportMeta },
+}).Parse(`{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Hall of Shame</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 10px; border-bottom: 1px solid #ddd; text-align: left; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1><a href="/">Hall of Shame</a></h1>
+{{end}}
+
+{{define "footer"}}{{with metadata}}<p style="color: #888; font-size: small">
+hall-of-shame {{.PluginVersion}} ({{.GitCommit}}, built {{.BuildDate}}, {{.GoVersion}}){{if .CAPIVersion}} &middot; CAPI {{.CAPIVersion}}{{end}}{{if .CLIVersion}} &middot; cf CLI {{.CLIVersion}}{{end}}
+</p>{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "apps"}}<table>
+<tr><th>Name</th><th>Org</th><th>Space</th><th>Instances</th><th>Alloc</th><th>AvgUse</th><th>Ratio</th><th>Waste</th></tr>
+{{range .}}<tr>
+<td><a href="/app/{{.GUID}}">{{.Name}}</a></td>
+<td>{{if .Org}}<a href="/org/{{path .Org}}">{{.Org}}</a>{{end}}</td>
+<td>{{or .SpaceName .Space}}</td>
+<td class="num">{{.Instances}}</td>
+<td class="num">{{size .MemoryAlloc}}</td>
+<td class="num">{{size .AvgMemoryUse}}</td>
+<td class="num">{{printf "%.2f" .Ratio}}</td>
+<td class="num">{{size .Waste}}</td>
+</tr>{{end}}
+</table>
+{{end}}
+
+{{define "leaderboard"}}{{template "header"}}
+<p><a href="/download.csv">Download CSV</a></p>
+<h2>Leaderboard</h2>
+{{template "apps" .Apps}}
+{{template "footer"}}{{end}}
+
+{{define "org"}}{{template "header"}}
+<h2>Org {{.Org}}</h2>
+<p>{{len .Apps}} apps, {{size .Waste}} wasted.</p>
+<table>
+<tr><th>Space</th><th>Apps</th><th>Waste</th></tr>
+{{range .Spaces}}<tr>
+<td>{{.Name}}</td>
+<td class="num">{{.Apps}}</td>
+<td class="num">{{size .Waste}}</td>
+</tr>{{end}}
+</table>
+{{template "apps" .Apps}}
+{{template "footer"}}{{end}}
+
+{{define "app"}}{{template "header"}}
+<h2>{{.Name}}</h2>
+<h3>Ratio</h3>
+<svg width="{{.Width}}" height="{{.Height}}" style="border: 1px solid #ddd">
+<polyline fill="none" stroke="#c0392b" stroke-width="2" points="{{.RatioPoints}}"/>
+</svg>
+<h3>Average use</h3>
+<svg width="{{.Width}}" height="{{.Height}}" style="border: 1px solid #ddd">
+<polyline fill="none" stroke="#2980b9" stroke-width="2" points="{{.UsagePoints}}"/>
+</svg>
+<table>
+<tr><th>Run</th><th>Instances</th><th>Alloc</th><th>AvgUse</th><th>Ratio</th></tr>
+{{range .Points}}<tr>
+<td>{{.StartedAt.Format "2006-01-02 15:04"}}</td>
+<td class="num">{{.App.Instances}}</td>
+<td class="num">{{size .App.MemoryAlloc}}</td>
+<td class="num">{{size .App.AvgMemoryUse}}</td>
+<td class="num">{{printf "%.2f" .App.Ratio}}</td>
+</tr>{{end}}
+</table>
+{{template "footer"}}{{end}}
+`))
+
+type uiApp struct {
+	appStatSummary
+	Waste int
+}
+
+// uiSpace is one space's line in an org's drill-down.
+type uiSpace struct {
+	Name  string
+	Apps  int
+	Waste int
+}
+
+func serveUI(opts *options) error {
+
+	store, err := openHistory(opts.historyDB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	latest := func() ([]uiApp, error) {
+		appStats, err := store.lastRun()
+		if err != nil {
+			return nil, err
+		}
+		sort.Stable(byRatio(appStats))
+
+		apps := make([]uiApp, len(appStats))
+		for i, app := range appStats {
+			apps[i] = uiApp{appStatSummary: app, Waste: app.Waste()}
+		}
+		return apps, nil
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		apps, err := latest()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		uiTemplates.ExecuteTemplate(w, "leaderboard", map[string]interface{}{"Apps": apps})
+	})
+
+	mux.HandleFunc("/org/", func(w http.ResponseWriter, r *http.Request) {
+		org := strings.TrimPrefix(r.URL.Path, "/org/")
+		apps, err := latest()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var inOrg []uiApp
+		var spaces []*uiSpace
+		bySpace := map[string]*uiSpace{}
+		var waste int
+		for _, app := range apps {
+			if app.Org != org {
+				continue
+			}
+			inOrg = append(inOrg, app)
+			waste += app.Waste
+
+			name := app.SpaceName
+			if name == "" {
+				name = app.Space
+			}
+			space, ok := bySpace[name]
+			if !ok {
+				space = &uiSpace{Name: name}
+				bySpace[name] = space
+				spaces = append(spaces, space)
+			}
+			space.Apps++
+			space.Waste += app.Waste
+		}
+		if len(inOrg) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		sort.SliceStable(spaces, func(i, j int) bool { return spaces[i].Waste > spaces[j].Waste })
+
+		uiTemplates.ExecuteTemplate(w, "org", map[string]interface{}{"Org": org, "Spaces": spaces, "Apps": inOrg, "Waste": waste})
+	})
+
+	mux.HandleFunc("/app/", func(w http.ResponseWriter, r *http.Request) {
+		points, err := store.appHistoryByGUID(strings.TrimPrefix(r.URL.Path, "/app/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(points) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		var ratios, usage []float64
+		for _, point := range points {
+			ratios = append(ratios, point.App.Ratio)
+			usage = append(usage, float64(point.App.AvgMemoryUse))
+		}
+
+		width, height := 600, 150
+		uiTemplates.ExecuteTemplate(w, "app", map[string]interface{}{
+			"Name":        points[len(points)-1].App.Name,
+			"Points":      points,
+			"Width":       width,
+			"Height":      height,
+			"RatioPoints": chartPoints(ratios, width, height),
+			"UsagePoints": chartPoints(usage, width, height),
+		})
+	})
+
+	mux.HandleFunc("/download.csv", func(w http.ResponseWriter, r *http.Request) {
+		appStats, err := store.lastRun()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sort.Stable(byRatio(appStats))
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="hall-of-shame.csv"`)
+		renderCSV(w, appStats)
+	})
+
+	registerAPI(mux, store)
+	registerMetrics(mux, store)
+
+	fmt.Printf("Serving hall-of-shame UI, API and /metrics on %s\n", opts.listen)
+	return http.ListenAndServe(opts.listen, mux)
+}
+
+func chartPoints(values []float64, width int, height int) string {
+
+	max := 0.0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	var points []string
+	for i, v := range values {
+		x := 0.0
+		if len(values) > 1 {
+			x = float64(i) / float64(len(values)-1) * float64(width)
+		}
+		y := float64(height)
+		if max > 0 {
+			y -= v / max * float64(height-10)
+		}
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+
+	return strings.Join(points, " ")
+}
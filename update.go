@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+const (
+	releasesURL         = "https://api.github.com/repos/danhigham/hall-of-shame/releases/latest"
+	updateCheckInterval = 24 * time.Hour
+
+	// releaseChecksumsAsset lists the SHA-256 of every binary in a release;
+	// update refuses to install a download that doesn't match it.
+	releaseChecksumsAsset = "checksums.txt"
+)
+
+var pluginVersion = plugin.VersionType{Major: 0, Minor: 1, Build: 1}
+
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	HTMLURL string        `json:"html_url"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+func versionString(version plugin.VersionType) string {
+	return fmt.Sprintf("%d.%d.%d", version.Major, version.Minor, version.Build)
+}
+
+// newerVersion compares dotted versions numerically, ignoring a leading "v".
+func newerVersion(latest string, current string) bool {
+	a := strings.Split(strings.TrimPrefix(latest, "v"), ".")
+	b := strings.Split(strings.TrimPrefix(current, "v"), ".")
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x, _ = strconv.Atoi(a[i])
+		}
+		if i < len(b) {
+			y, _ = strconv.Atoi(b[i])
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+func fetchLatestRelease(timeout time.Duration) (*githubRelease, error) {
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(releasesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("checking for updates failed: %s", resp.Status)
+	}
+
+	release := &githubRelease{}
+	if err := json.NewDecoder(resp.Body).Decode(release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// startUpdateCheck looks for a newer release in the background, at most once
+// a day. The returned function prints a notice if one was found, without
+// waiting on a slow network.
+func startUpdateCheck() func(io.Writer) {
+
+	stamp := defaultStatePath("update-check")
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < updateCheckInterval {
+		return func(io.Writer) {}
+	}
+
+	found := make(chan *githubRelease, 1)
+	go func() {
+		release, err := fetchLatestRelease(5 * time.Second)
+		if err != nil {
+			found <- nil
+			return
+		}
+		os.MkdirAll(filepath.Dir(stamp), 0755)
+		ioutil.WriteFile(stamp, []byte(release.TagName), 0644)
+		found <- release
+	}()
+
+	return func(w io.Writer) {
+		select {
+		case release := <-found:
+			if release != nil && newerVersion(release.TagName, versionString(pluginVersion)) {
+				fmt.Fprintf(w, "\nhall-of-shame %s is available (running %s); run `cf hall-of-shame update` to install it.\n",
+					release.TagName, versionString(pluginVersion))
+			}
+		default:
+		}
+	}
+}
+
+// releaseAssetName is the exact name each release publishes the plugin
+// binary under for this platform.
+func releaseAssetName() string {
+	name := fmt.Sprintf("hall-of-shame-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func releaseAsset(release *githubRelease, name string) (string, error) {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadURL, nil
+		}
+	}
+	return "", fmt.Errorf("release %s has no %s, see %s", release.TagName, name, release.HTMLURL)
+}
+
+// releaseChecksum reads the SHA-256 of asset from the release's
+// checksums.txt, in sha256sum format.
+func releaseChecksum(release *githubRelease, asset string) (string, error) {
+
+	url, err := releaseAsset(release, releaseChecksumsAsset)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := download(url)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s in release %s has no checksum for %s", releaseChecksumsAsset, release.TagName, asset)
+}
+
+func download(url string) ([]byte, error) {
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("downloading %s failed: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func runUpdateCommand(cliConnection plugin.CliConnection) error {
+
+	release, err := fetchLatestRelease(30 * time.Second)
+	if err != nil {
+		return err
+	}
+
+	current := versionString(pluginVersion)
+	if !newerVersion(release.TagName, current) {
+		fmt.Printf("hall-of-shame %s is the latest release.\n", current)
+		return nil
+	}
+
+	name := releaseAssetName()
+	url, err := releaseAsset(release, name)
+	if err != nil {
+		return err
+	}
+	checksum, err := releaseChecksum(release, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Install hall-of-shame %s (running %s)? [y/N] ", release.TagName, current)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return nil
+	}
+
+	binary, err := download(url)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(binary)
+	if actual := hex.EncodeToString(digest[:]); actual != checksum {
+		return fmt.Errorf("%s does not match %s (sha256 %s, expected %s); not installing", name, releaseChecksumsAsset, actual, checksum)
+	}
+
+	file, err := ioutil.TempFile("", "hall-of-shame-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(binary)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0755); err != nil {
+		return err
+	}
+
+	_, err = cliConnection.CliCommand("install-plugin", file.Name(), "-f")
+	return err
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReleaseAssetMatchesExactly(t *testing.T) {
+
+	release := &githubRelease{TagName: "v1.0.0"}
+	for _, name := range []string{"hall-of-shame-linux-amd64.sig", "hall-of-shame-linux-amd64", "checksums.txt"} {
+		release.Assets = append(release.Assets, githubAsset{Name: name, BrowserDownloadURL: "https://example.com/" + name})
+	}
+
+	url, err := releaseAsset(release, "hall-of-shame-linux-amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://example.com/hall-of-shame-linux-amd64" {
+		t.Errorf("matched %s", url)
+	}
+	if _, err := releaseAsset(release, "hall-of-shame-linux-arm64"); err == nil {
+		t.Error("expected no asset for linux/arm64")
+	}
+}
+
+func TestReleaseChecksum(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "AAAA  hall-of-shame-darwin-arm64\nBBBB *hall-of-shame-linux-amd64\n")
+	}))
+	defer server.Close()
+
+	release := &githubRelease{TagName: "v1.0.0", Assets: []githubAsset{{Name: releaseChecksumsAsset, BrowserDownloadURL: server.URL}}}
+
+	checksum, err := releaseChecksum(release, "hall-of-shame-linux-amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum != "bbbb" {
+		t.Errorf("checksum = %s, want bbbb", checksum)
+	}
+	if _, err := releaseChecksum(release, "hall-of-shame-windows-amd64.exe"); err == nil {
+		t.Error("expected an error for an asset missing from checksums.txt")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type uploader interface {
+	Upload(key string, body []byte, contentType string) error
+}
+
+func newUploaders(opts *options) []uploader {
+	var uploaders []uploader
+
+	if opts.s3Bucket != "" {
+		uploaders = append(uploaders, newS3Uploader(opts))
+	}
+	if opts.azureAccount != "" && opts.azureContainer != "" {
+		uploaders = append(uploaders, newAzureUploader(opts))
+	}
+	if opts.gcsBucket != "" {
+		uploaders = append(uploaders, newGCSUploader(opts))
+	}
+	if opts.archiveDir != "" {
+		uploaders = append(uploaders, dirUploader{dir: opts.archiveDir})
+	}
+
+	return uploaders
+}
+
+// uploadReports archives every report format. With --compress each report
+// is gzipped and named <foundation>-hall-of-shame-<stamp>.<ext>.gz directly
+// under the prefix, so retention rules can match on the name alone. With
+// --sign-key each report is accompanied by .sha256 and .sig files.
+func uploadReports(uploaders []uploader, opts *options, appStats []appStatSummary) error {
+	stamp := time.Now().UTC().Format("2006-01-02T150405Z")
+	prefix := strings.Trim(opts.archivePrefix, "/")
+
+	for _, format := range reportFormats {
+		var buffer bytes.Buffer
+		if err := format.render(&buffer, appStats); err != nil {
+			return err
+		}
+
+		key := strings.TrimPrefix(fmt.Sprintf("%s/%s/hall-of-shame.%s", prefix, stamp, format.extension), "/")
+		body, contentType := buffer.Bytes(), format.contentType
+		if opts.compress {
+			name := fmt.Sprintf("hall-of-shame-%s.%s.gz", stamp, format.extension)
+			if foundation := teamFileUnsafe.ReplaceAllString(reportMeta.Foundation, "_"); foundation != "" {
+				name = foundation + "-" + name
+			}
+			key = strings.TrimPrefix(prefix+"/"+name, "/")
+
+			var err error
+			if body, err = gzipBytes(body); err != nil {
+				return err
+			}
+			contentType = "application/gzip"
+		}
+
+		for _, u := range uploaders {
+			if err := u.Upload(key, body, contentType); err != nil {
+				return err
+			}
+		}
+
+		if opts.signer == nil {
+			continue
+		}
+		files, err := signatureFiles(opts.signer, key, body)
+		if err != nil {
+			return err
+		}
+		for name, data := range files {
+			for _, u := range uploaders {
+				if err := u.Upload(name, data, "application/octet-stream"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// dirUploader archives reports to a local directory, for retention on a
+// mounted volume rather than object storage.
+type dirUploader struct {
+	dir string
+}
+
+func (u dirUploader) Upload(key string, body []byte, contentType string) error {
+	path := filepath.Join(u.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0644)
+}
+
+func escapeObjectKey(key string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
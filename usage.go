@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/olekukonko/tablewriter"
+)
+
+type AppUsageEventResults struct {
+	NextUrl   string           `json:"next_url"`
+	Resources []*AppUsageEvent `json:"resources"`
+}
+
+type AppUsageEvent struct {
+	Metadata struct {
+		Guid      string    `json:"guid"`
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"metadata"`
+	Entity struct {
+		State                 string `json:"state"`
+		AppGuid               string `json:"app_guid"`
+		AppName               string `json:"app_name"`
+		SpaceGuid             string `json:"space_guid"`
+		SpaceName             string `json:"space_name"`
+		OrgGuid               string `json:"org_guid"`
+		InstanceCount         int    `json:"instance_count"`
+		MemoryInMbPerInstance int    `json:"memory_in_mb_per_instance"`
+	} `json:"entity"`
+}
+
+type memoryHours struct {
+	Name        string  `json:"name"`
+	GUID        string  `json:"guid"`
+	Space       string  `json:"space"`
+	SpaceName   string  `json:"space_name"`
+	Org         string  `json:"org"`
+	OrgGUID     string  `json:"org_guid"`
+	Allocated   float64 `json:"allocated_gb_hours"`
+	Unused      float64 `json:"unused_gb_hours"`
+	Reclaimable float64 `json:"reclaimable_gb_hours"`
+
+	IsolationSegment string `json:"isolation_segment,omitempty"`
+	Instances        int    `json:"-"`
+	DiskAlloc        int    `json:"-"`
+}
+
+// GetAppUsageEvents reads usage events newest first and stops paging once
+// it is past since, so the cost follows the window rather than the age of
+// the foundation. The last page may reach back before since; those events
+// still tell computeMemoryHours what was running when the window opened.
+func (hallOfShame *HallOfShame) GetAppUsageEvents(cliConnection plugin.CliConnection, since time.Time) ([]*AppUsageEvent, error) {
+
+	var events []*AppUsageEvent
+
+	query := "/v2/app_usage_events?results-per-page=100&order-direction=desc"
+	for query != "" {
+		output, err := cliConnection.CliCommandWithoutTerminalOutput("curl", query)
+		if err != nil {
+			return nil, err
+		}
+
+		res := AppUsageEventResults{}
+		if err := json.Unmarshal([]byte(strings.Join(output, "")), &res); err != nil {
+			return nil, err
+		}
+
+		events = append(events, res.Resources...)
+		query = res.NextUrl
+
+		if n := len(res.Resources); n > 0 && res.Resources[n-1].Metadata.CreatedAt.Before(since) {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+// computeMemoryHours integrates each app's allocated memory (instances x
+// quota while STARTED) over the window. When an app's oldest event is a
+// STOPPED inside the window, it is taken to have been running at that size
+// since the window opened. Apps with no events at all are left to the
+// caller, which knows their current size.
+func computeMemoryHours(events []*AppUsageEvent, start time.Time, end time.Time) map[string]*memoryHours {
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Metadata.CreatedAt.Before(events[j].Metadata.CreatedAt)
+	})
+
+	type appState struct {
+		running bool
+		memory  int
+		since   time.Time
+	}
+
+	states := map[string]*appState{}
+	hours := map[string]*memoryHours{}
+
+	accumulate := func(guid string, state *appState, until time.Time) {
+		from := state.since
+		if from.Before(start) {
+			from = start
+		}
+		if until.After(end) {
+			until = end
+		}
+		if state.running && until.After(from) {
+			hours[guid].Allocated += float64(state.memory) / 1024 * until.Sub(from).Hours()
+		}
+	}
+
+	for _, event := range events {
+		entity := event.Entity
+		if _, ok := hours[entity.AppGuid]; !ok {
+			hours[entity.AppGuid] = &memoryHours{Name: entity.AppName, GUID: entity.AppGuid, Space: entity.SpaceGuid, SpaceName: entity.SpaceName}
+			states[entity.AppGuid] = &appState{}
+		}
+
+		state := states[entity.AppGuid]
+		if state.since.IsZero() && entity.State == "STOPPED" && event.Metadata.CreatedAt.After(start) {
+			state.running = true
+			state.memory = entity.MemoryInMbPerInstance * entity.InstanceCount
+			state.since = start
+		}
+		switch entity.State {
+		case "STARTED", "STOPPED":
+			accumulate(entity.AppGuid, state, event.Metadata.CreatedAt)
+			state.running = entity.State == "STARTED"
+			state.memory = entity.MemoryInMbPerInstance * entity.InstanceCount
+			state.since = event.Metadata.CreatedAt
+		}
+	}
+
+	for guid, state := range states {
+		accumulate(guid, state, end)
+	}
+
+	return hours
+}
+
+func (hallOfShame *HallOfShame) collectMemoryHours(cliConnection plugin.CliConnection, opts *options) ([]*memoryHours, error) {
+
+	appStats, err := hallOfShame.collect(cliConnection, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	start := end.Add(-opts.usageWindow)
+
+	events, err := hallOfShame.GetAppUsageEvents(cliConnection, start)
+	if err != nil {
+		return nil, err
+	}
+
+	hours := computeMemoryHours(events, start, end)
+
+	// Apps with no usage events since before the window ran at their
+	// current size throughout it.
+	quiet := map[string]bool{}
+	for _, app := range appStats {
+		if app.State == "STOPPED" {
+			continue
+		}
+		if _, ok := hours[app.GUID]; !ok {
+			hours[app.GUID] = &memoryHours{Name: app.Name, GUID: app.GUID, Space: app.Space, SpaceName: app.SpaceName}
+			quiet[app.GUID] = true
+		}
+		if quiet[app.GUID] {
+			hours[app.GUID].Allocated += float64(app.MemoryAlloc*app.Instances) / (1 << 30) * opts.usageWindow.Hours()
+		}
+	}
+
+	utilization := map[string]float64{}
+	reclaimable := map[string]float64{}
+	current := map[string]appStatSummary{}
+	for _, app := range appStats {
+		current[app.GUID] = app
+		if app.MemoryAlloc > 0 {
+			utilization[app.GUID] = float64(app.AvgMemoryUse) / float64(app.MemoryAlloc)
+			reclaimable[app.GUID] = float64(app.MemoryAlloc-app.RecommendedAlloc()) / float64(app.MemoryAlloc)
+		}
+	}
+
+	names := newNameResolver(hallOfShame, cliConnection)
+
+	var apps []*memoryHours
+	for guid, app := range hours {
+		if app.Allocated == 0 {
+			continue
+		}
+		if util, ok := utilization[guid]; ok && util < 1 {
+			app.Unused = app.Allocated * (1 - util)
+		}
+		if fraction := reclaimable[guid]; fraction > 0 {
+			app.Reclaimable = app.Allocated * fraction
+		}
+		if stat, ok := current[guid]; ok {
+			app.IsolationSegment, app.Instances, app.DiskAlloc = stat.IsolationSegment, stat.Instances, stat.DiskAlloc
+		}
+		spaceNames := names.resolve(app.Space)
+		app.Org, app.OrgGUID = spaceNames.org, spaceNames.orgGuid
+		apps = append(apps, app)
+	}
+
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Unused > apps[j].Unused })
+
+	return apps, nil
+}
+
+func (hallOfShame *HallOfShame) runUsageCommand(cliConnection plugin.CliConnection, opts *options) error {
+
+	apps, err := hallOfShame.collectMemoryHours(cliConnection, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(apps)
+	}
+
+	renderMemoryHours(os.Stdout, apps, opts.usageWindow)
+	return nil
+}
+
+func renderMemoryHours(w io.Writer, apps []*memoryHours, window time.Duration) {
+
+	orgs := map[string]*memoryHours{}
+	var orgNames []string
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Org", "Space", "Alloc GB-h", "Unused GB-h"})
+
+	for _, app := range apps {
+		table.Append([]string{app.Name, app.Org, app.SpaceName, fmt.Sprintf("%.1f", app.Allocated), fmt.Sprintf("%.1f", app.Unused)})
+
+		if _, ok := orgs[app.Org]; !ok {
+			orgs[app.Org] = &memoryHours{Org: app.Org}
+			orgNames = append(orgNames, app.Org)
+		}
+		orgs[app.Org].Allocated += app.Allocated
+		orgs[app.Org].Unused += app.Unused
+	}
+
+	table.Render()
+
+	sort.Strings(orgNames)
+
+	fmt.Fprintf(w, "\nMemory-hours per org over the last %s:\n", window)
+	orgTable := tablewriter.NewWriter(w)
+	orgTable.SetHeader([]string{"Org", "Alloc GB-h", "Unused GB-h"})
+	for _, name := range orgNames {
+		org := orgs[name]
+		orgTable.Append([]string{name, fmt.Sprintf("%.1f", org.Allocated), fmt.Sprintf("%.1f", org.Unused)})
+	}
+	orgTable.Render()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+func usageEvent(guid string, state string, at time.Time, instances int, memory int) *AppUsageEvent {
+	event := &AppUsageEvent{}
+	event.Metadata.CreatedAt = at
+	event.Entity.AppGuid, event.Entity.AppName = guid, guid
+	event.Entity.State, event.Entity.InstanceCount, event.Entity.MemoryInMbPerInstance = state, instances, memory
+	return event
+}
+
+func TestComputeMemoryHours(t *testing.T) {
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+
+	hours := computeMemoryHours([]*AppUsageEvent{
+		// Started before the window, 2 x 1G: counted from the window start.
+		usageEvent("early", "STARTED", start.Add(-5*time.Hour), 2, 1024),
+		// First seen stopping inside the window: it ran from the start.
+		usageEvent("stopped", "STOPPED", start.Add(4*time.Hour), 1, 2048),
+		// Started inside the window.
+		usageEvent("late", "STARTED", start.Add(8*time.Hour), 1, 512),
+	}, start, end)
+
+	for guid, want := range map[string]float64{"early": 20, "stopped": 8, "late": 1} {
+		if got := hours[guid].Allocated; math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s allocated %.2f GB-h, want %.2f", guid, got, want)
+		}
+	}
+}
+
+// pagedUsageEvents serves app usage events newest first, one event a page.
+type pagedUsageEvents struct {
+	plugin.CliConnection
+	events   []*AppUsageEvent
+	requests int
+}
+
+func (connection *pagedUsageEvents) CliCommandWithoutTerminalOutput(args ...string) ([]string, error) {
+
+	connection.requests++
+	page := 0
+	if i := strings.Index(args[1], "&page="); i >= 0 {
+		fmt.Sscanf(args[1][i+len("&page="):], "%d", &page)
+	}
+
+	res := AppUsageEventResults{Resources: connection.events[page : page+1]}
+	if page+1 < len(connection.events) {
+		res.NextUrl = fmt.Sprintf("/v2/app_usage_events?results-per-page=1&order-direction=desc&page=%d", page+1)
+	}
+	data, err := json.Marshal(res)
+	return []string{string(data)}, err
+}
+
+func TestGetAppUsageEventsStopsAtWindow(t *testing.T) {
+
+	now := time.Now()
+	connection := &pagedUsageEvents{events: []*AppUsageEvent{
+		usageEvent("a", "STARTED", now.Add(-1*time.Hour), 1, 256),
+		usageEvent("b", "STARTED", now.Add(-2*time.Hour), 1, 256),
+		usageEvent("c", "STARTED", now.Add(-48*time.Hour), 1, 256),
+		usageEvent("d", "STARTED", now.Add(-96*time.Hour), 1, 256),
+	}}
+
+	events, err := new(HallOfShame).GetAppUsageEvents(connection, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || connection.requests != 3 {
+		t.Errorf("fetched %d events in %d requests, want 3 in 3: paging should stop at the first page older than the window", len(events), connection.requests)
+	}
+}
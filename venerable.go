@@ -0,0 +1,28 @@
+package main
+
+import "strings"
+
+// venerableSuffixes are the names blue-green deploy tooling gives the old
+// copy of an app while the new one takes over its routes. "-blue" and
+// "-green" are left out: in those schemes either half may be the live one.
+var venerableSuffixes = []string{"-venerable", "-old"}
+
+// markVenerable flags apps whose name is another app's name in the same
+// space plus a blue-green suffix.
+func markVenerable(appStats []appStatSummary) {
+
+	names := map[string]bool{}
+	for _, app := range appStats {
+		names[app.Space+"/"+app.Name] = true
+	}
+
+	for i := range appStats {
+		app := &appStats[i]
+		for _, suffix := range venerableSuffixes {
+			if strings.HasSuffix(app.Name, suffix) && names[app.Space+"/"+strings.TrimSuffix(app.Name, suffix)] {
+				app.Venerable = true
+				break
+			}
+		}
+	}
+}
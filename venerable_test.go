@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestMarkVenerable(t *testing.T) {
+
+	appStats := []appStatSummary{
+		{Name: "web", Space: "prod"},
+		{Name: "web-venerable", Space: "prod"},
+		{Name: "web-old", Space: "prod"},
+		{Name: "web-green", Space: "prod"},
+		{Name: "api-venerable", Space: "prod"},
+		{Name: "web-old", Space: "dev"},
+	}
+	markVenerable(appStats)
+
+	want := []bool{false, true, true, false, false, false}
+	for i, app := range appStats {
+		if app.Venerable != want[i] {
+			t.Errorf("%s in %s: venerable = %t, want %t", app.Name, app.Space, app.Venerable, want[i])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/danhigham/hall-of-shame/pkg/render"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X main.gitCommit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	gitCommit = "unknown"
+	buildDate = "unknown"
+)
+
+// reportMetadata records what produced a report so a run can be reproduced.
+type reportMetadata = render.Metadata
+
+var reportMeta = reportMetadata{
+	PluginVersion: versionString(pluginVersion),
+	GitCommit:     gitCommit,
+	BuildDate:     buildDate,
+	GoVersion:     runtime.Version(),
+}
+
+func renderVersion(w io.Writer) {
+	fmt.Fprintf(w, "hall-of-shame %s\n", versionString(pluginVersion))
+	fmt.Fprintf(w, "  commit:     %s\n", gitCommit)
+	fmt.Fprintf(w, "  built:      %s\n", buildDate)
+	fmt.Fprintf(w, "  go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// collectMetadata fills in the foundation side of reportMeta. Either version
+// may be missing, e.g. when rendering a saved snapshot while logged out.
+func collectMetadata(cliConnection plugin.CliConnection) {
+
+	reportMeta.GeneratedAt = time.Now().UTC()
+
+	if api, err := cliConnection.ApiEndpoint(); err == nil {
+		if u, err := url.Parse(api); err == nil {
+			reportMeta.Foundation = u.Host
+		}
+	}
+
+	if version, err := cliConnection.ApiVersion(); err == nil {
+		reportMeta.CAPIVersion = version
+	}
+
+	// `cf version` prints "cf version 8.7.1+9c81242.2023-06-15".
+	if output, err := cliConnection.CliCommandWithoutTerminalOutput("version"); err == nil && len(output) > 0 {
+		reportMeta.CLIVersion = strings.TrimPrefix(strings.TrimSpace(output[0]), "cf version ")
+	}
+}